@@ -2,9 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/FChris/towg/convert"
+	"github.com/FChris/towg/parse"
+	"github.com/FChris/towg/task"
 	"github.com/urfave/cli"
 	"os"
 	"sort"
+	"strings"
+	"time"
 )
 
 // RunCLI executes the Command Line Interface for towg
@@ -14,9 +19,17 @@ func RunCLI(messages chan string) {
 	app.Usage = "Todos with go - A small go tool to manage todo files"
 	app.Version = "0.0.1"
 
-	app.Commands = []cli.Command{
-		printCommand(), addCommand(), switchStatusCommand(), deleteCommand(), redateCommand(),
+	app.Flags = append([]cli.Flag{gitFlag()}, orderFlags()...)
+	app.Before = func(c *cli.Context) error {
+		// The shell runs the app for every command without the global flags, so git mode is never switched off
+		gitMode = gitMode || c.Bool("git")
+		if err := setOrders(c); err != nil {
+			fmt.Println(err)
+			return err
+		}
+		return nil
 	}
+	app.Commands = commands()
 
 	sort.Sort(cli.FlagsByName(app.Flags))
 	sort.Sort(cli.CommandsByName(app.Commands))
@@ -26,31 +39,94 @@ func RunCLI(messages chan string) {
 	messages <- "Finished"
 }
 
+func commands() []cli.Command {
+	return []cli.Command{
+		printCommand(), addCommand(), switchStatusCommand(), deleteCommand(), redateCommand(),
+		importCommand(), exportCommand(), tuiCommand(), shellCommand(), serveCommand(), logCommand(),
+		mergeCommand(), editCommand(), moveCommand(), upCommand(), downCommand(), searchCommand(),
+		completionCommand(), completeCommand(),
+	}
+}
+
 func printCommand() cli.Command {
 	return cli.Command{
 
 		Name:  "print",
 		Usage: "print all tasks for a time period",
-		Flags: []cli.Flag{fileFlag(), dateFlag()},
+		Flags: []cli.Flag{
+			fileFlag(),
+			dateFlag(),
+			cli.StringFlag{
+				Name: "undated",
+				Usage: "date for todos which are not below a date heading, like the checklist of a README. " +
+					"\n\tAllows dates as 'dd.mm.yy', or as 'yesterday', 'today', 'tomorrow'. If no date is given " +
+					"\n\tthese todos are skipped",
+			},
+			cli.StringFlag{
+				Name: "format",
+				Usage: "output format. One of 'detailed', 'compact', 'table', 'plain' or a Go template like " +
+					"\n\t'{{.DateString}} {{.Description}}' which is printed for every todo. Default is 'detailed'",
+			},
+			cli.StringFlag{
+				Name:  "color",
+				Usage: "'auto', 'always' or 'never'. Auto colors the output if it is a terminal and NO_COLOR is not set",
+			},
+			cli.BoolFlag{
+				Name:  "watch, w",
+				Usage: "keep running and print the list again whenever the file changes, highlighting what changed",
+			},
+		},
 		Action: func(c *cli.Context) error {
 			fileName := c.String("file")
 			if fileName == "" {
 				fileName = fileNameDefault
 			}
-			list, err := parseFromFile(fileName)
-			if err != nil {
-				return err
+			var undated time.Time
+			var err error
+			if c.String("undated") != "" {
+				undated, err = dateByDescription(c.String("undated"))
+				if err != nil {
+					fmt.Println(err)
+					return err
+				}
 			}
+			load := func() (task.DayList, error) {
+				if undated.IsZero() {
+					return loadList(fileName)
+				}
+				return parseFromFileUndated(fileName, undated)
+			}
+
 			date := c.String("date")
 			if date == "" {
 				date = today
 			}
-			periodList, err := dayListByPeriod(list, date)
+			colors, err := useColors(c.String("color"))
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+			opts := printOptions{format: c.String("format"), colors: colors}
+			if c.Bool("watch") {
+				return watchDayList(fileName, date, load, opts)
+			}
+
+			list, err := load()
+			if err != nil {
+				return err
+			}
+			periodList, err := printPeriod(list, date)
 			if err != nil {
 				fmt.Println(err)
 				return err
 			}
-			printDayList(periodList)
+			if err = printDayList(os.Stdout, periodList, opts); err != nil {
+				fmt.Println(err)
+				return err
+			}
+			// The view is only a convenience for later commands, so failing to write it is not an error
+			newView(date, periodList).save(fileName)
+			warnConflicts(fileName)
 			return nil
 		},
 	}
@@ -73,24 +149,23 @@ func addCommand() cli.Command {
 			if fileName == "" {
 				fileName = fileNameDefault
 			}
-			list, err := parseFromFile(fileName)
-			if err != nil {
-				fmt.Println(err)
-				return err
-			}
-
 			date := c.String("date")
 			if date == "" {
 				date = today
 			}
 			text := c.String("text")
-			list, err = addTodoFromDesc(list, text, date)
+			err := updateList(fileName, func(list task.DayList) (task.DayList, string, error) {
+				list, err := addTodoFromDesc(list, text, date)
+				if err != nil {
+					return list, "", err
+				}
+				day, _ := dateByDescription(date)
+				return list, changeMessage("add", task.ParseTodo(text, false), day, ""), nil
+			})
 			if err != nil {
 				fmt.Println(err)
-				return err
 			}
-			save(list, fileName)
-			return nil
+			return err
 		},
 	}
 }
@@ -98,54 +173,141 @@ func addCommand() cli.Command {
 func switchStatusCommand() cli.Command {
 	return cli.Command{
 		Name:  "switch",
-		Usage: "switches the status of the n-th todo in the list of todos for the given date",
-		Flags: []cli.Flag{
+		Usage: "switches the status of the selected todos in the list of todos for the given date",
+		Flags: append([]cli.Flag{fileFlag(), dateFlag()}, selectionFlags("have to be switched")...),
+		Action: func(c *cli.Context) error {
+			fileName := c.String("file")
+			if fileName == "" {
+				fileName = fileNameDefault
+			}
+			err := updateList(fileName, func(list task.DayList) (task.DayList, string, error) {
+				selected, err := selectTodos(c, list, fileName)
+				if err != nil {
+					return list, "", err
+				}
+				var messages []string
+				for _, s := range selected {
+					switchTodoStatus(list, s.date, indexOfTodo(list, s.date, s.todo.Text()))
+					messages = append(messages, changeMessage("switch", s.todo, s.date, statusName(!s.todo.Complete)))
+				}
+				return list, joinMessages("switch", messages), nil
+			})
+			if err != nil {
+				fmt.Println(err)
+			}
+			return err
+		},
+	}
+}
+
+func deleteCommand() cli.Command {
+	return cli.Command{
+		Name:  "delete",
+		Usage: "deletes the selected todos in the list of todos for the given date",
+		Flags: append([]cli.Flag{fileFlag(), dateFlag()}, selectionFlags("will be deleted")...),
+		Action: func(c *cli.Context) error {
+			fileName := c.String("file")
+			if fileName == "" {
+				fileName = fileNameDefault
+			}
+			err := updateList(fileName, func(list task.DayList) (task.DayList, string, error) {
+				selected, err := selectTodos(c, list, fileName)
+				if err != nil {
+					return list, "", err
+				}
+				var messages []string
+				for _, s := range selected {
+					if err = list.DeleteTodo(s.date, indexOfTodo(list, s.date, s.todo.Text())); err != nil {
+						return list, "", err
+					}
+					messages = append(messages, changeMessage("delete", s.todo, s.date, ""))
+				}
+				return list, joinMessages("delete", messages), nil
+			})
+			if err != nil {
+				fmt.Println(err)
+			}
+			return err
+		},
+	}
+}
+
+func redateCommand() cli.Command {
+	return cli.Command{
+		Name:  "redate",
+		Usage: "redates the selected todos in the list of given todos by date to the new date",
+		Flags: append([]cli.Flag{
 			fileFlag(),
 			dateFlag(),
-			cli.IntFlag{
-				Name:  "number, n",
-				Usage: "number of the todo of which the status has to be switched",
+			cli.StringFlag{
+				Name:  "newdate",
+				Usage: "new date for the todos. Allows dates as 'dd.mm.yy',or as 'yesterday', 'today', 'tomorrow'",
 			},
-		},
+		}, selectionFlags("will be redated")...),
 		Action: func(c *cli.Context) error {
 			fileName := c.String("file")
 			if fileName == "" {
 				fileName = fileNameDefault
 			}
-			listByFile, err := parseFromFile(fileName)
+			newDateDesc := c.String("newdate")
+			if newDateDesc == "" {
+				newDateDesc = today
+			}
+			newDate, err := dateByDescription(newDateDesc)
 			if err != nil {
+				err = fmt.Errorf("Error while parsing new date: %s", err)
 				fmt.Println(err)
 				return err
 			}
 
-			date := c.String("date")
-			if date == "" {
-				date = today
-			}
-			listByPeriod, err := dayListByPeriod(listByFile, date)
+			var selected []selectedTodo
+			err = updateList(fileName, func(list task.DayList) (task.DayList, string, error) {
+				selected, err = selectTodos(c, list, fileName)
+				if err != nil {
+					return list, "", err
+				}
+				var messages []string
+				for _, s := range selected {
+					list, err = changeDateOfTodo(list, s.date, indexOfTodo(list, s.date, s.todo.Text()), newDate)
+					if err != nil {
+						return list, "", err
+					}
+					messages = append(messages, changeMessage("redate", s.todo, s.date, newDate.Format(parse.Timeformat)))
+				}
+				return list, joinMessages("redate", messages), nil
+			})
 			if err != nil {
 				fmt.Println(err)
 				return err
 			}
 
-			number := c.Int("number")
-			switchTodoStatus(listByFile, listByPeriod, number)
-			save(listByFile, fileName)
+			// Keep the numbers of the todos valid in the last view
+			if !c.IsSet("date") {
+				if v, err := loadView(fileName); err == nil && v != nil {
+					for _, s := range selected {
+						v.redate(s.number, newDate)
+					}
+					v.save(fileName)
+				}
+			}
 			return nil
 		},
 	}
 }
 
-func deleteCommand() cli.Command {
+func importCommand() cli.Command {
 	return cli.Command{
-		Name:  "delete",
-		Usage: "switches the status of the n-th todo in the list of todos for the given date",
+		Name:  "import",
+		Usage: "adds all todos from a file in another format to the todo list",
 		Flags: []cli.Flag{
 			fileFlag(),
-			dateFlag(),
-			cli.IntFlag{
-				Name:  "number, n",
-				Usage: "number of the todo of which the status has to be switched",
+			cli.StringFlag{
+				Name:  "from",
+				Usage: "format of the imported file. Supported formats are " + strings.Join(convert.Names(), ", "),
+			},
+			cli.StringFlag{
+				Name:  "input, i",
+				Usage: "file to import. If no file name is given the todos are read from stdin",
 			},
 		},
 		Action: func(c *cli.Context) error {
@@ -153,48 +315,59 @@ func deleteCommand() cli.Command {
 			if fileName == "" {
 				fileName = fileNameDefault
 			}
-			listByFile, err := parseFromFile(fileName)
+			// The input is read before the file is locked, since it might be slow stdin
+			imported, err := importDayList(c.String("input"), c.String("from"))
 			if err != nil {
 				fmt.Println(err)
 				return err
 			}
-
-			date := c.String("date")
-			if date == "" {
-				date = today
+			source := c.String("input")
+			if source == "" {
+				source = "stdin"
 			}
-			listByPeriod, err := dayListByPeriod(listByFile, date)
-			if err != nil {
-				fmt.Println(err)
-				return err
+			count := 0
+			for _, day := range imported {
+				count += len(day.Todos)
 			}
-
-			number := c.Int("number")
-			listByFile, err = deleteTodo(listByFile, listByPeriod, number-1)
+			message := fmt.Sprintf("import: %d todos from %s", count, source)
+			err = updateList(fileName, func(list task.DayList) (task.DayList, string, error) {
+				return addDayList(list, imported), message, nil
+			})
 			if err != nil {
 				fmt.Println(err)
-				return err
 			}
-			save(listByFile, fileName)
-			return nil
+			return err
 		},
 	}
 }
 
-func redateCommand() cli.Command {
+func exportCommand() cli.Command {
 	return cli.Command{
-		Name:  "redate",
-		Usage: "redates the n-th todo in the list of given todos by date to the new date",
+		Name:  "export",
+		Usage: "writes all todos for a time period to a file in another format",
 		Flags: []cli.Flag{
 			fileFlag(),
-			dateFlag(),
-			cli.IntFlag{
-				Name:  "number, n",
-				Usage: "number of the todo which will be redated",
+			cli.StringFlag{
+				Name: "date, d",
+				Usage: "time period to export. Allows dates as 'dd.mm.yy', 'dd.mm.yy-dd.mm.yy' " +
+					"or as \n\t'yesterday', 'today', 'tomorrow', 'week' for the current week or '-' for all days. " +
+					"\n\tIf no date is given all days will be exported",
 			},
 			cli.StringFlag{
-				Name:  "newdate",
-				Usage: "new date for the todo. Allows dates as 'dd.mm.yy',or as 'yesterday', 'today', 'tomorrow'",
+				Name:  "to",
+				Usage: "format of the exported file. Supported formats are " + strings.Join(convert.Names(), ", "),
+			},
+			cli.StringFlag{
+				Name:  "output, o",
+				Usage: "file to write to. If no file name is given the todos are written to stdout",
+			},
+			cli.StringFlag{
+				Name:  "page-break",
+				Usage: "'day' or 'week' to start a new page for every day or week in pdf files",
+			},
+			cli.BoolFlag{
+				Name:  "pocket",
+				Usage: "use a compact layout for A6 paper in pdf files",
 			},
 		},
 		Action: func(c *cli.Context) error {
@@ -202,7 +375,7 @@ func redateCommand() cli.Command {
 			if fileName == "" {
 				fileName = fileNameDefault
 			}
-			listByFile, err := parseFromFile(fileName)
+			list, err := loadList(fileName)
 			if err != nil {
 				fmt.Println(err)
 				return err
@@ -210,26 +383,20 @@ func redateCommand() cli.Command {
 
 			date := c.String("date")
 			if date == "" {
-				date = today
+				date = "-"
 			}
-			listByPeriod, err := dayListByPeriod(listByFile, date)
+			periodList, err := dayListByPeriod(list, date)
 			if err != nil {
 				fmt.Println(err)
 				return err
 			}
 
-			number := c.Int("number")
-			newDate := c.String("newdate")
-			if newDate == "" {
-				newDate = today
-			}
-			listByFile, err = changeDateOfTodo(listByFile, listByPeriod, newDate, number)
+			opts := convert.Options{PageBreak: c.String("page-break"), Pocket: c.Bool("pocket")}
+			err = exportDayList(periodList, c.String("output"), c.String("to"), opts)
 			if err != nil {
 				fmt.Println(err)
-				return err
 			}
-			save(listByFile, fileName)
-			return nil
+			return err
 		},
 	}
 }
@@ -245,7 +412,7 @@ func dateFlag() cli.Flag {
 	return cli.StringFlag{
 		Name: "date, d",
 		Usage: "date or time for the command. Allows dates as 'dd.mm.yy', 'dd.mm.yy-dd.mm.yy' " +
-			"or as \n\t'yesterday', 'today', 'tomorrow', or '-' for all days. If no date is given " + today +
-			"\n\twill be used as a default",
+			"or as \n\t'yesterday', 'today', 'tomorrow', 'week' for the current week or '-' for all days. " +
+			"\n\tIf no date is given " + today + " will be used as a default",
 	}
 }
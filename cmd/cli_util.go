@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"github.com/FChris/towg/convert"
 	"github.com/FChris/towg/parse"
 	"github.com/FChris/towg/task"
 	"io"
+	"io/ioutil"
 	"os"
-	"sort"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -15,76 +19,300 @@ const (
 	yesterday       string = "yesterday"
 	today           string = "today"
 	tomorrow        string = "tomorrow"
+	week            string = "week"
 	fileNameDefault string = "tasks.todo"
 )
 
 func parseFromFile(fileName string) (list task.DayList, err error) {
-	file, err := os.OpenFile(fileName, os.O_RDONLY, 0600)
+	return parseFromFileUndated(fileName, time.Time{})
+}
+
+// parseFromFileUndated parses the file like parseFromFile but assigns todos that are not below a date heading,
+// like the checklist of a README, to the undated date instead of skipping them.
+//
+// The content of the file is remembered, so that save can merge changes other programs make to the file until then.
+func parseFromFileUndated(fileName string, undated time.Time) (list task.DayList, err error) {
+	data, err := ioutil.ReadFile(fileName)
 	if err != nil {
 		err = fmt.Errorf("Error while opening file: %s", err)
 		return list, err
-
 	}
-	defer file.Close()
 
-	list, err = parseData(file)
+	list, conflicts, err := parseDataConflicts(bytes.NewReader(data), undated)
 	if err != nil {
 		err = fmt.Errorf("Parsing from file: %s", err)
+		return list, err
 	}
-	return list, err
+	if undated.IsZero() {
+		rememberFile(fileName, data, list, conflicts)
+	}
+	return list, nil
 }
 
 func parseData(r io.Reader) (list task.DayList, err error) {
+	list, _, err = parseDataConflicts(r, time.Time{})
+	return list, err
+}
+
+// parseDataConflicts parses the data like parseData and returns the lines of unresolved conflicts as well
+func parseDataConflicts(r io.Reader, undated time.Time) (list task.DayList, conflicts []int, err error) {
+	store := task.NewStoreInOrder(nil, fileOrder)
 	parser := parse.NewParser(r)
+	parser.DefaultDate = undated
+	parser.Order = fileOrder.Todos
 
-	for {
-		day, e := parser.Parse()
-		if e != nil {
-			err = fmt.Errorf("Error while parsing data: %s", e)
-			return
-		}
+	for parser.Next() {
+		day := parser.Day()
+		store.Insert(day.Date, day.Todos)
+	}
+	if err = parser.Err(); err != nil {
+		err = fmt.Errorf("Error while parsing data: %s", err)
+		return
+	}
 
-		if day.Date.IsZero() {
-			return
-		}
+	return store.DayList(), parser.Conflicts(), nil
+}
+
+// save writes the list to the file. The list is written to a temporary file in the same directory, synced to disk
+// and then renamed over the file, so the file holds either the old or the new list even if towg crashes while
+// saving. The old list is kept as backup in a hidden file next to it.
+//
+// If another program changed the file since towg read it, its changes are merged into the list first. save returns
+// the list that was written, which includes those changes, and a conflictsError if some todos could not be merged.
+//
+// Files with lines towg does not write, like the prose of a README, are not saved, since they would be lost.
+//
+// save does not lock the file. Commands use updateList, which holds the lock during the whole parse-modify-save
+// cycle.
+func save(dayList task.DayList, fileName string) (task.DayList, error) {
+	if err := checkFormat(fileName); err != nil {
+		return dayList, err
+	}
+	dayList, conflicts, err := mergeChanges(dayList, fileName)
+	if err != nil {
+		return dayList, err
+	}
+	var data bytes.Buffer
+	if err = writeDayList(&data, dayList, conflicts); err != nil {
+		return dayList, fmt.Errorf("Error while writing %s: %s", fileName, err)
+	}
 
-		list.SetDay(day)
+	perm := os.FileMode(0600)
+	if info, err := os.Stat(fileName); err == nil {
+		perm = info.Mode().Perm()
 	}
-}
 
-func save(dayList task.DayList, fileName string) error {
-	err := os.Remove("." + fileName + ".bak")
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("Error while deleting old backup: %s", err)
+	tmp, err := ioutil.TempFile(filepath.Dir(fileName), "."+filepath.Base(fileName)+".tmp")
+	if err != nil {
+		return dayList, fmt.Errorf("Error while creating temporary file: %s", err)
 	}
+	// Once the temporary file was renamed this fails, which is fine
+	defer os.Remove(tmp.Name())
 
-	err = os.Rename(fileName, "."+fileName+".bak")
+	_, err = tmp.Write(data.Bytes())
+	if err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
-		return fmt.Errorf("Backing up existing todo list: %s", err)
+		return dayList, fmt.Errorf("Error while writing %s: %s", fileName, err)
 	}
 
-	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE, 0600)
+	if err = backup(fileName); err != nil {
+		return dayList, err
+	}
+	if err = os.Rename(tmp.Name(), fileName); err != nil {
+		return dayList, fmt.Errorf("Error while replacing %s: %s", fileName, err)
+	}
+	// Make the rename itself durable
+	syncDir(filepath.Dir(fileName))
+
+	if len(conflicts) == 0 {
+		rememberFile(fileName, data.Bytes(), dayList, nil)
+		return dayList, nil
+	}
+	// The file now reads like the list with our version of the conflicts
+	dayList, lines, err := parseDataConflicts(bytes.NewReader(data.Bytes()), time.Time{})
 	if err != nil {
-		return fmt.Errorf("Error while opening file for writing : %s", err)
+		return dayList, err
+	}
+	rememberFile(fileName, data.Bytes(), dayList, lines)
+	return dayList, conflictsError{fileName: fileName, lines: lines}
+}
+
+// checkFormat returns an error if the file has lines which would be lost by writing it in the format of towg, like
+// prose, headings without date or todos which are not below a date heading
+func checkFormat(fileName string) error {
+	file, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Error while opening file: %s", err)
 	}
 	defer file.Close()
 
-	sort.Sort(dayList)
+	parser := parse.NewParser(file)
+	for parser.Next() {
+	}
+	if lines := parser.Foreign(); len(lines) > 0 {
+		return fmt.Errorf("%s is not written in the format of towg. Saving it would drop the lines which are no "+
+			"todos below a date heading, starting with line %d. Change the file in an editor instead", fileName,
+			lines[0])
+	}
+	return nil
+}
+
+// writeDayList writes the list in the format of towg files. The conflicts are written to their days between
+// conflict markers.
+func writeDayList(w io.Writer, dayList task.DayList, conflicts []task.Conflict) error {
+	out := bufio.NewWriter(w)
+	fileOrder.Sort(dayList)
 
 	for _, day := range dayList {
-		sort.Sort(day.Todos)
 		dateString := day.Date.Format(parse.Timeformat)
-		file.WriteString("\n# " + dateString + "\n\n")
+		out.WriteString("\n# " + dateString + "\n\n")
 
 		for _, todo := range day.Todos {
-			file.WriteString(todo.String())
-			file.WriteString("  \n")
+			out.WriteString(todo.String())
+			out.WriteString("  \n")
 		}
+		writeConflicts(out, day.Date, conflicts)
+	}
+
+	// bufio.Writer keeps the first error, so it is returned here
+	return out.Flush()
+}
+
+// backup replaces the backup of the file by its current content
+func backup(fileName string) error {
+	backupName := filepath.Join(filepath.Dir(fileName), "."+filepath.Base(fileName)+".bak")
+	err := os.Remove(backupName)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Error while deleting old backup: %s", err)
+	}
+
+	err = os.Link(fileName, backupName)
+	if err == nil || os.IsNotExist(err) {
+		return nil
 	}
 
+	// Not every file system supports hard links
+	data, err := ioutil.ReadFile(fileName)
+	if err == nil {
+		err = ioutil.WriteFile(backupName, data, 0600)
+	}
+	if err != nil {
+		return fmt.Errorf("Backing up existing todo list: %s", err)
+	}
 	return nil
 }
 
+// updateList runs the parse-modify-save cycle of a command. It holds the lock of the file from reading until the
+// changed list is written, so towg processes running at the same time do not overwrite each others changes. update
+// gets an empty list if the file does not exist yet. It returns the changed list and a description of the change,
+// which is the commit message in git mode.
+func updateList(fileName string, update func(list task.DayList) (task.DayList, string, error)) error {
+	if s := currentSession; s == nil || s.fileName != fileName {
+		unlock, err := lockFile(fileName)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	list, err := loadListOrEmpty(fileName)
+	if err != nil {
+		return err
+	}
+	// update gets a copy, so that an update which fails halfway leaves the list of a shell session untouched
+	list, message, err := update(task.NewStoreInOrder(list, fileOrder).DayList())
+	if err != nil {
+		return err
+	}
+	return saveList(list, fileName, message)
+}
+
+// saveLocked saves the list while holding the lock of the file and returns the saved list like save. In git mode
+// the file is committed with the message.
+func saveLocked(list task.DayList, fileName string, message string) (task.DayList, error) {
+	unlock, err := lockFile(fileName)
+	if err != nil {
+		return list, err
+	}
+	defer unlock()
+
+	list, err = save(list, fileName)
+	if err != nil {
+		return list, err
+	}
+	return list, commitChange(fileName, message)
+}
+
+// lockFileName returns the name of the file which is locked while the todo file is changed. The todo file itself
+// cannot be locked, because it is replaced on every save.
+func lockFileName(fileName string) string {
+	return filepath.Join(filepath.Dir(fileName), "."+filepath.Base(fileName)+".lock")
+}
+
+// importDayList reads a DayList in the given format from the file with the given name or from stdin if the name is
+// empty
+func importDayList(fileName string, formatName string) (task.DayList, error) {
+	format, err := convert.ByName(formatName)
+	if err != nil {
+		return nil, err
+	}
+	if format.Decode == nil {
+		return nil, fmt.Errorf("Format %s can not be imported", formatName)
+	}
+
+	var r io.Reader = os.Stdin
+	if fileName != "" {
+		file, err := os.Open(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("Error while opening file: %s", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	list, err := format.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("Importing from %s: %s", formatName, err)
+	}
+	return list, nil
+}
+
+// exportDayList writes the DayList in the given format to the file with the given name or to stdout if the name is
+// empty
+func exportDayList(list task.DayList, fileName string, formatName string, opts convert.Options) error {
+	format, err := convert.ByName(formatName)
+	if err != nil {
+		return err
+	}
+	if format.Encode == nil {
+		return fmt.Errorf("Format %s can not be exported", formatName)
+	}
+
+	if fileName == "" {
+		return format.Encode(os.Stdout, list, opts)
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("Error while opening file for writing : %s", err)
+	}
+	if err = format.Encode(file, list, opts); err != nil {
+		file.Close()
+		return fmt.Errorf("Exporting to %s: %s", formatName, err)
+	}
+	return file.Close()
+}
+
 // addTodoFromDesc returns an updated original list with a new todo based on desc inserted into day with date or an
 // error and an unchanged original in case something goes wrong
 func addTodoFromDesc(original task.DayList, desc string, date string) (task.DayList, error) {
@@ -112,81 +340,102 @@ func addTodoFromDesc(original task.DayList, desc string, date string) (task.DayL
 }
 
 func addDayList(original, new task.DayList) task.DayList {
+	store := task.NewStoreInOrder(original, fileOrder)
 	for _, newDay := range new {
-		d := original.DayByDate(newDay.Date)
-		d.Todos.Insert(newDay.Todos)
-		original.SetDay(d)
+		store.Insert(newDay.Date, newDay.Todos)
 	}
 
-	return original
+	return store.DayList()
 }
 
-func switchTodoStatus(original, new task.DayList, id int) {
-	for i, day := range new {
-		for j, todo := range day.Todos {
-			if i+j+1 == id {
-				todo.Complete = !todo.Complete
-				day.Todos.InsertTodo(todo)
+// switchTodoStatus switches the status of the todo at index ind of the day with the given date
+func switchTodoStatus(original task.DayList, date time.Time, ind int) {
+	day := original.DayByDate(date)
+	todo := day.Todos[ind]
+	todo.Complete = !todo.Complete
+	day.Todos.InsertTodoInOrder(todo, fileOrder.Todos)
+	original.SetDay(day)
+}
 
-				original.SetDay(day)
-				return
-			}
+// indexOfTodo returns the index of the todo with the given text within the day with the given date
+func indexOfTodo(list task.DayList, date time.Time, text string) int {
+	for i, todo := range list.DayByDate(date).Todos {
+		if todo.Text() == text {
+			return i
 		}
 	}
+	return -1
 }
 
-// deleteTodo removes the n-th todo from the new list and then updates the original list with all the remaining
-// todos from new and then returns original then
-//
-// Note that new has to be a sublist of original
-func deleteTodo(original task.DayList, subList task.DayList, n int) (new task.DayList, err error) {
-	new = original
-	d, ind, err := subList.TransformToDayBasedIndex(n)
+// changeDateOfTodo moves the todo at index ind of the day with the given date to newDate. It fails if newDate
+// already has a todo with the same text.
+func changeDateOfTodo(original task.DayList, date time.Time, ind int, newDate time.Time) (task.DayList, error) {
+	todo := original.DayByDate(date).Todos[ind]
+	// Texts are unique within a day, so the todos would be merged
+	if !ignoreTime(date).Equal(ignoreTime(newDate)) && indexOfTodo(original, newDate, todo.Text()) >= 0 {
+		return original, fmt.Errorf("There already is a todo %q on %s", todo.Text(),
+			newDate.Format(parse.Timeformat))
+	}
+	err := original.DeleteTodo(date, ind)
 	if err != nil {
-		return
+		return original, fmt.Errorf("Error while deleting todo from old day: %s", err)
 	}
-	err = new.DeleteTodo(d, ind)
-	return
-}
 
-func changeDateOfTodo(original task.DayList, new task.DayList, dayDescription string, n int) (task.DayList, error) {
-	var date time.Time
+	original.InsertTodoInOrder(newDate, todo, fileOrder)
+	return original, nil
+}
 
-	if isRelativeDayDescription(dayDescription) {
-		date = dateByRelativeDayDescription(dayDescription)
-	} else {
-		var err error
-		date, err = time.Parse(parse.Timeformat, dayDescription)
-		if err != nil {
-			err = fmt.Errorf("Error while parsing from date: %s", err)
-			return original, err
-		}
+// editTodo replaces the todo at index ind of the day with the given date by the todo described by text. The
+// status of the todo is kept, and its position in ManualOrder.
+func editTodo(original task.DayList, date time.Time, ind int, text string) (task.DayList, error) {
+	old := original.DayByDate(date).Todos[ind]
+	todo := task.ParseTodo(strings.TrimSpace(text), old.Complete)
+	if todo.Description == "" {
+		return original, fmt.Errorf("The description of a todo must not be empty")
+	}
+	// Texts are unique within a day, so the other todo would be overwritten
+	if todo.Text() != old.Text() && indexOfTodo(original, date, todo.Text()) >= 0 {
+		return original, fmt.Errorf("There already is a todo %q on %s", todo.Text(), date.Format(parse.Timeformat))
 	}
 
-	d, ind, err := new.TransformToDayBasedIndex(n - 1)
+	day := original.DayByDate(date)
+	day.Todos[ind] = todo
+	fileOrder.Todos.Sort(day.Todos)
+	original.SetDay(day)
+	return original, nil
+}
+
+func dayListByPeriod(original task.DayList, period string) (task.DayList, error) {
+	fromDate, toDate, err := periodBounds(period)
 	if err != nil {
-		return original, fmt.Errorf("Error while retrieving specified todo: %s", err)
+		return task.DayList{}, err
 	}
 
-	todo := original.DayByDate(d).Todos[ind]
-	err = original.DeleteTodo(d, ind)
-	if err != nil {
-		return original, fmt.Errorf("Error while deleting todo from old day: %s", err)
+	fileOrder.Days.Sort(original)
+
+	var periodDayList task.DayList
+	for _, day := range original {
+
+		if inTimeSpan(fromDate, toDate, day.Date) {
+			periodDayList = append(periodDayList, day)
+		}
 	}
 
-	original.InsertTodo(date, todo)
-	return original, nil
+	return periodDayList, nil
 }
 
-func dayListByPeriod(original task.DayList, period string) (task.DayList, error) {
+// periodBounds returns the first and the last date of the period given like to the date flag
+func periodBounds(period string) (fromDate, toDate time.Time, err error) {
 	dayDescription := strings.ToLower(period)
-	var fromDate time.Time
-	var toDate time.Time
-	var err error
 
 	if isRelativeDayDescription(dayDescription) {
 		fromDate = dateByRelativeDayDescription(dayDescription)
 		toDate = fromDate
+	} else if dayDescription == week {
+		// The week starts on Monday
+		now := time.Now()
+		fromDate = now.AddDate(0, 0, -(int(now.Weekday())+6)%7)
+		toDate = fromDate.AddDate(0, 0, 6)
 	} else if strings.IndexRune(period, '-') >= 0 {
 		timeFrame := strings.Split(period, "-")
 		timeFrame = deleteEmpty(timeFrame)
@@ -197,39 +446,35 @@ func dayListByPeriod(original task.DayList, period string) (task.DayList, error)
 			toDate, err = time.Parse(parse.Timeformat, timeFrame[1])
 			if err != nil {
 				err = fmt.Errorf("Error while parsing to date: %s", err)
-				return task.DayList{}, err
+				return
 			}
 		} else if len(timeFrame) > 0 {
 			fromDate, err = time.Parse(parse.Timeformat, timeFrame[0])
 			if err != nil {
 				err = fmt.Errorf("Error while parsing from date: %s", err)
-				return task.DayList{}, err
+				return
 			}
 		}
 	} else {
 		fromDate, err = time.Parse(parse.Timeformat, period)
 		if err != nil {
 			err = fmt.Errorf("Error while parsing from date: %s", err)
-			return task.DayList{}, err
+			return
 		}
 		toDate = fromDate
 	}
 
-	fromDate = ignoreTime(fromDate)
-	toDate = ignoreTime(toDate)
-
-	sort.Sort(original)
-
-	var periodDayList task.DayList
-	for _, day := range original {
+	return ignoreTime(fromDate), ignoreTime(toDate), nil
+}
 
-		if inTimeSpan(fromDate, toDate, day.Date) {
-			periodDayList.SetDay(day)
-		}
+// dateByDescription parses a date given as 'dd.mm.yy' or as 'yesterday', 'today' or 'tomorrow'
+func dateByDescription(dayDescription string) (time.Time, error) {
+	if isRelativeDayDescription(dayDescription) {
+		return ignoreTime(dateByRelativeDayDescription(dayDescription)), nil
 	}
-
-	return periodDayList, nil
+	return time.Parse(parse.Timeformat, dayDescription)
 }
+
 func dateByRelativeDayDescription(dayDescription string) time.Time {
 	var date time.Time
 	switch dayDescription {
@@ -246,17 +491,6 @@ func isRelativeDayDescription(dayDescription string) bool {
 	return dayDescription == yesterday || dayDescription == today || dayDescription == tomorrow
 }
 
-func printDayList(list task.DayList) {
-	for _, day := range list {
-		fmt.Println()
-		dateString := day.Date.Format(parse.Timeformat)
-		fmt.Println(dateString)
-		for _, todo := range day.Todos {
-			fmt.Println(todo)
-		}
-	}
-}
-
 func inTimeSpan(from, to, check time.Time) bool {
 	return (check.After(from) && check.Before(to)) || check == to || check == from
 }
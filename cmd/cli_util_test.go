@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"github.com/FChris/towg/task"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func testList() task.DayList {
+	date := time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC)
+	return task.DayList{{Date: date, Todos: task.TodoList{{Description: "Todo 1"}, {Description: "Todo 2"}}}}
+}
+
+func TestSave_WriteFailure(t *testing.T) {
+	err := writeDayList(failingWriter{}, testList(), nil)
+	assert.NotEqual(t, nil, err, "Error of the writer is not returned by writeDayList")
+
+	dir, err := ioutil.TempDir("", "towg")
+	assert.Equal(t, nil, err, "Error for creating the directory is not nil")
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "missing", "test.todo")
+	_, err = save(testList(), fileName)
+	assert.NotEqual(t, nil, err, "Error for saving into a missing directory is nil")
+	assert.Equal(t, false, saved(err), "Failed save is reported as saved")
+	_, err = os.Stat(fileName)
+	assert.True(t, os.IsNotExist(err), "File exists after a failed save")
+}
+
+func TestSave_Markdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "towg")
+	assert.Equal(t, nil, err, "Error for creating the directory is not nil")
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "README.md")
+	markdown := "# Project\n\nSome prose.\n\n## 17.07.17\n\n- [ ] Todo 1\n- [ ] Todo 2\n\n```\ncode\n```\n"
+	err = ioutil.WriteFile(fileName, []byte(markdown), 0600)
+	assert.Equal(t, nil, err, "Error for writing the file is not nil")
+
+	list, err := parseFromFile(fileName)
+	assert.Equal(t, nil, err, "Error for parsing the file is not nil")
+	switchTodoStatus(list, list[0].Date, 0)
+	_, err = save(list, fileName)
+	assert.NotEqual(t, nil, err, "Saving a Markdown file with prose is not refused")
+	assert.Equal(t, false, saved(err), "Refused save is reported as saved")
+
+	data, err := ioutil.ReadFile(fileName)
+	assert.Equal(t, nil, err, "Error for reading the file is not nil")
+	assert.Equal(t, markdown, string(data), "Prose of the Markdown file was not kept")
+
+	towg := "\n# 17.07.17\n\n- [ ] Todo 1  \n- [ ] Todo 2  \n"
+	err = ioutil.WriteFile(fileName, []byte(towg), 0600)
+	assert.Equal(t, nil, err, "Error for writing the file is not nil")
+	list, err = parseFromFile(fileName)
+	assert.Equal(t, nil, err, "Error for parsing the file is not nil")
+	switchTodoStatus(list, list[0].Date, 0)
+	_, err = save(list, fileName)
+	assert.Equal(t, nil, err, "Error for saving a file in the format of towg is not nil")
+}
+
+func TestChangeDateOfTodo(t *testing.T) {
+	date := time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC)
+	list := task.DayList{
+		{Date: date, Todos: task.TodoList{{Description: "Todo 1"}}},
+		{Date: date.AddDate(0, 0, -1), Todos: task.TodoList{{Description: "Todo 1", Complete: true}}},
+	}
+
+	_, err := changeDateOfTodo(list, date.AddDate(0, 0, -1), 0, date)
+	assert.NotEqual(t, nil, err, "Redating onto a day with the same todo is not reported")
+
+	list, err = changeDateOfTodo(list, date, 0, date.AddDate(0, 0, 1))
+	assert.Equal(t, nil, err, "Error for redating the todo is not nil")
+	assert.Equal(
+		t,
+		task.DayList{
+			{Date: date.AddDate(0, 0, 1), Todos: task.TodoList{{Description: "Todo 1"}}},
+			{Date: date, Todos: task.TodoList{}},
+			{Date: date.AddDate(0, 0, -1), Todos: task.TodoList{{Description: "Todo 1", Complete: true}}},
+		},
+		list,
+		"Todo is not redated")
+}
@@ -0,0 +1,319 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/FChris/towg/parse"
+	"github.com/FChris/towg/task"
+	"github.com/urfave/cli"
+	"strings"
+)
+
+// completeFiles is printed by __complete instead of candidates if the shell should complete todo files itself
+const completeFiles = ":files"
+
+// completionScripts are printed by the completion command. They run 'towg __complete' with the words of the command
+// line up to the word being completed, which prints one candidate per line, optionally followed by a tab and a
+// description.
+var completionScripts = map[string]string{
+	"bash": `_towg() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" line value
+    local -a values described
+    # --date=<TAB> is split into "--date" and "=", where the value is still empty
+    [ "$cur" = "=" ] && cur=""
+    while IFS= read -r line; do
+        if [ "$line" = "` + completeFiles + `" ]; then
+            compopt -o filenames
+            COMPREPLY=($(compgen -f -X '!*.todo' -- "$cur") $(compgen -d -- "$cur"))
+            return
+        fi
+        value="${line%%	*}"
+        case "$value" in "$cur"*) ;; *) continue ;; esac
+        values+=("$value")
+        if [ "$value" != "$line" ]; then
+            described+=("$value  -- ${line#*	}")
+        else
+            described+=("$value")
+        fi
+    done < <(towg __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)
+    # Descriptions are only listed, a single candidate is inserted without it
+    if [ ${#values[@]} -eq 1 ]; then
+        COMPREPLY=("${values[0]}")
+    else
+        COMPREPLY=("${described[@]}")
+    fi
+}
+complete -F _towg towg
+`,
+
+	"zsh": `#compdef towg
+
+_towg() {
+    local line
+    local -a lines values described
+    lines=("${(@f)$(towg __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ "${lines[1]}" == "` + completeFiles + `" ]]; then
+        _files -g '*.todo'
+        return
+    fi
+    for line in "${lines[@]}"; do
+        [[ -z "$line" ]] && continue
+        values+=("${line%%$'\t'*}")
+        if [[ "$line" == *$'\t'* ]]; then
+            described+=("${line%%$'\t'*}  -- ${line#*$'\t'}")
+        else
+            described+=("$line")
+        fi
+    done
+    compadd -l -d described -a values
+}
+
+compdef _towg towg
+`,
+
+	"fish": `function __towg_complete
+    set -l tokens (commandline -opc)
+    set -l current (commandline -ct)
+    set -l lines (towg __complete $tokens[2..-1] "$current" 2>/dev/null)
+    if test "$lines[1]" = "` + completeFiles + `"
+        __fish_complete_suffix .todo
+        return
+    end
+    printf '%s\n' $lines
+end
+
+complete -c towg -f -a '(__towg_complete)'
+`,
+}
+
+func completionCommand() cli.Command {
+	return cli.Command{
+		Name: "completion",
+		Usage: "prints the completion script for bash, zsh or fish, like 'source <(towg completion bash)' in " +
+			"~/.bashrc",
+		ArgsUsage: "bash|zsh|fish",
+		Action: func(c *cli.Context) error {
+			script, ok := completionScripts[c.Args().First()]
+			if !ok {
+				err := fmt.Errorf("Unknown shell %q, expected bash, zsh or fish", c.Args().First())
+				fmt.Println(err)
+				return err
+			}
+			fmt.Print(script)
+			return nil
+		},
+	}
+}
+
+// completeCommand is run by the completion scripts with the words of the command line
+func completeCommand() cli.Command {
+	return cli.Command{
+		Name:            "__complete",
+		Hidden:          true,
+		SkipFlagParsing: true,
+		Action: func(c *cli.Context) error {
+			for _, candidate := range completions(c.App, c.Args()) {
+				fmt.Println(candidate)
+			}
+			return nil
+		},
+	}
+}
+
+// completions returns the candidates for the last of the words, which follow the name of the app. The value of a
+// flag is completed as a separate word and within the word of the flag like --date=tod. Bash splits the latter into
+// "--date", "=" and "tod", which is completed like the separate value. Todo files are not completed within the word
+// of the flag, since the shells only complete them as words of their own.
+func completions(app *cli.App, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current, words := words[len(words)-1], words[:len(words)-1]
+
+	var command *cli.Command
+	flags := app.Flags
+	values := map[string]string{}
+	var pending cli.Flag
+	for _, word := range words {
+		switch {
+		case pending != nil && word == "=":
+		case pending != nil:
+			for _, name := range flagNames(pending) {
+				values[name] = word
+			}
+			pending = nil
+		case strings.HasPrefix(word, "-") && len(word) > 1:
+			name := strings.TrimLeft(word, "-")
+			value := ""
+			if i := strings.IndexByte(name, '='); i >= 0 {
+				name, value = name[:i], name[i+1:]
+			}
+			f := findFlag(flags, name)
+			if f == nil || !takesValue(f) {
+				continue
+			}
+			if value == "" {
+				pending = f
+			}
+			for _, name := range flagNames(f) {
+				values[name] = value
+			}
+		case command == nil:
+			if command = app.Command(word); command != nil {
+				flags = command.Flags
+			}
+		}
+	}
+
+	if i := strings.IndexByte(current, '='); i > 0 && strings.HasPrefix(current, "-") && pending == nil {
+		f := findFlag(flags, strings.TrimLeft(current[:i], "-"))
+		if f == nil || !takesValue(f) {
+			return nil
+		}
+		var candidates []string
+		for _, candidate := range flagValueCompletions(flagNames(f)[0], values) {
+			if candidate != completeFiles {
+				candidates = append(candidates, current[:i+1]+candidate)
+			}
+		}
+		return candidates
+	}
+
+	switch {
+	case pending != nil:
+		return flagValueCompletions(flagNames(pending)[0], values)
+	case strings.HasPrefix(current, "-"):
+		var candidates []string
+		for _, f := range flags {
+			for _, name := range flagNames(f) {
+				prefix := "--"
+				if len(name) == 1 {
+					prefix = "-"
+				}
+				candidates = append(candidates, prefix+name+"\t"+flagUsage(f))
+			}
+		}
+		return candidates
+	case command == nil:
+		var candidates []string
+		for _, c := range app.Commands {
+			if !c.Hidden {
+				candidates = append(candidates, c.Name+"\t"+c.Usage)
+			}
+		}
+		return candidates
+	case command.Name == "completion":
+		return []string{"bash", "zsh", "fish"}
+	}
+	return nil
+}
+
+// flagValueCompletions returns the candidates for the value of the flag with the given name. values are the flags
+// given before by all of their names.
+func flagValueCompletions(name string, values map[string]string) []string {
+	switch name {
+	case "file":
+		return []string{completeFiles}
+	case "date", "newdate":
+		return dateCompletions(values["file"])
+	case "number", "to":
+		return numberCompletions(values["file"], values["date"])
+	case "order", "print-order":
+		return []string{"status", "priority", "alphabetic", "created", "tag", "manual"}
+	case "day-order", "print-day-order":
+		return []string{"newest", "oldest"}
+	case "format":
+		return []string{"detailed", "compact", "table", "plain"}
+	case "color":
+		return []string{"auto", "always", "never"}
+	case "policy":
+		return []string{mergeNewest, mergeDone, mergeAsk}
+	}
+	return nil
+}
+
+// dateCompletions returns the relative dates and the dates of the days of the file
+func dateCompletions(fileName string) []string {
+	candidates := []string{yesterday, today, tomorrow, week + "\tthe current week"}
+	list, err := completionList(fileName)
+	if err != nil {
+		return candidates
+	}
+	for _, day := range list {
+		done := 0
+		for _, todo := range day.Todos {
+			if todo.Complete {
+				done++
+			}
+		}
+		candidates = append(candidates, fmt.Sprintf("%s\t%d/%d done", day.Date.Format(parse.Timeformat), done,
+			len(day.Todos)))
+	}
+	return candidates
+}
+
+// numberCompletions returns the numbers of the todos of the period like other commands select them, described by
+// the todos
+func numberCompletions(fileName, period string) []string {
+	list, err := completionList(fileName)
+	if err != nil {
+		return nil
+	}
+	numbered, _, err := numberedTodosOfPeriod(list, fileName, period)
+	if err != nil {
+		return nil
+	}
+	var candidates []string
+	for _, s := range numbered {
+		if !s.missing {
+			candidates = append(candidates, fmt.Sprintf("%d\t%s %s", s.number, s.date.Format(parse.Timeformat),
+				s.todo))
+		}
+	}
+	return candidates
+}
+
+// completionList reads the todo file, or the default file if no file is given
+func completionList(fileName string) (task.DayList, error) {
+	if fileName == "" {
+		fileName = fileNameDefault
+	}
+	return parseFromFile(fileName)
+}
+
+// findFlag returns the flag with the name
+func findFlag(flags []cli.Flag, name string) cli.Flag {
+	for _, f := range flags {
+		for _, n := range flagNames(f) {
+			if n == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// flagNames returns the long and short names of the flag
+func flagNames(f cli.Flag) []string {
+	var names []string
+	for _, name := range strings.Split(f.GetName(), ",") {
+		names = append(names, strings.TrimSpace(name))
+	}
+	return names
+}
+
+// takesValue returns true unless the flag is a switch
+func takesValue(f cli.Flag) bool {
+	switch f.(type) {
+	case cli.BoolFlag, cli.BoolTFlag:
+		return false
+	}
+	return true
+}
+
+// flagUsage returns the first line of the usage of the flag
+func flagUsage(f cli.Flag) string {
+	if f, ok := f.(cli.DocGenerationFlag); ok {
+		return strings.TrimSpace(strings.SplitN(f.GetUsage(), "\n", 2)[0])
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompletions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "towg")
+	assert.Equal(t, nil, err, "Error for creating the directory is not nil")
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "test.todo")
+	data := "\n# 18.07.17\n\n- [ ] Todo 3  \n\n# 17.07.17\n\n- [x] Todo 1  \n- [ ] Todo 2  \n"
+	assert.Equal(t, nil, ioutil.WriteFile(fileName, []byte(data), 0600), "Error for writing the file is not nil")
+
+	app := testApp()
+	app.Flags = append([]cli.Flag{gitFlag()}, orderFlags()...)
+	dates := []string{"yesterday", "today", "tomorrow", "week\tthe current week", "18.07.17\t0/1 done",
+		"17.07.17\t1/2 done"}
+
+	tests := []struct {
+		words    []string
+		expected []string
+	}{
+		{[]string{"print", "-f", ""}, []string{completeFiles}},
+		{[]string{"print", "--file", ""}, []string{completeFiles}},
+		{[]string{"print", "-f", fileName, "-d", ""}, dates},
+		{[]string{"print", "-f", fileName, "--date", "tod"}, dates},
+		{[]string{"print", "--file=" + fileName, "-d", ""}, dates},
+		{[]string{"print", "-f", fileName, "--date=tod"}, []string{"--date=yesterday", "--date=today",
+			"--date=tomorrow", "--date=week\tthe current week", "--date=18.07.17\t0/1 done",
+			"--date=17.07.17\t1/2 done"}},
+		// Bash splits --date=tod into three words
+		{[]string{"print", "-f", fileName, "--date", "=", "tod"}, dates},
+		{[]string{"print", "-f", fileName, "--date", "="}, dates},
+		{[]string{"print", "--file=tod"}, nil},
+		{[]string{"print", "--all=tod"}, nil},
+		{[]string{"switch", "-f", fileName, "-d", "17.07.17", "-n", ""},
+			[]string{"1\t17.07.17 - [x] Todo 1", "2\t17.07.17 - [ ] Todo 2"}},
+		{[]string{"switch", "--date=18.07.17", "-f", fileName, "--number", ""}, []string{"1\t18.07.17 - [ ] Todo 3"}},
+		{[]string{"switch", "-f", fileName, "-d", "17.07.17", "--number=2"}, []string{"--number=1\t17.07.17 - [x] Todo 1",
+			"--number=2\t17.07.17 - [ ] Todo 2"}},
+		{[]string{"--order", ""}, []string{"status", "priority", "alphabetic", "created", "tag", "manual"}},
+		{[]string{"print", "--format", ""}, []string{"detailed", "compact", "table", "plain"}},
+		{[]string{"completion", ""}, []string{"bash", "zsh", "fish"}},
+		{[]string{"print", "-f", fileName, ""}, nil},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, completions(app, test.words), "Wrong completions for %q", test.words)
+	}
+
+	names := func(candidates []string) []string {
+		var names []string
+		for _, c := range candidates {
+			names = append(names, strings.SplitN(c, "\t", 2)[0])
+		}
+		return names
+	}
+	commands := names(completions(app, []string{"pri"}))
+	assert.Contains(t, commands, "print", "Command names are not completed")
+	assert.Contains(t, commands, "switch", "Command names are not completed")
+	assert.NotContains(t, commands, "__complete", "Hidden command is completed")
+	assert.Equal(t, commands, names(completions(app, nil)), "Commands are not completed without words")
+	assert.Equal(t, commands, names(completions(app, []string{"--git", ""})), "Commands are not completed after a switch")
+
+	flags := names(completions(app, []string{"print", "-"}))
+	assert.Contains(t, flags, "--date", "Long flag names are not completed")
+	assert.Contains(t, flags, "-d", "Short flag names are not completed")
+	assert.NotContains(t, flags, "--number", "Flags of other commands are completed")
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"github.com/FChris/towg/parse"
+	"github.com/FChris/towg/task"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Markers around the two versions of a todo that could not be merged. The parser reads the first version.
+const (
+	conflictStart     = "<<<<<<< ours (towg)"
+	conflictSeparator = "======="
+	conflictEnd       = ">>>>>>> theirs (changed on disk)"
+)
+
+// fileState is the content of a todo file when towg read or wrote it last. Before saving, the file is compared to
+// it to find out whether another program changed the file in the meantime.
+type fileState struct {
+	hash [sha256.Size]byte
+
+	// list is the base of the three-way merge with changes of other programs
+	list task.DayList
+
+	// conflicts are the lines of unresolved conflicts in the file
+	conflicts []int
+}
+
+// fileStates holds the state of every todo file this process read or wrote, by absolute path
+var (
+	fileStatesMu sync.Mutex
+	fileStates   = map[string]fileState{}
+)
+
+func fileStateKey(fileName string) string {
+	if abs, err := filepath.Abs(fileName); err == nil {
+		return abs
+	}
+	return filepath.Clean(fileName)
+}
+
+// rememberFile records the content of the file and the list parsed from it
+func rememberFile(fileName string, data []byte, list task.DayList, conflicts []int) {
+	fileStatesMu.Lock()
+	defer fileStatesMu.Unlock()
+	fileStates[fileStateKey(fileName)] = fileState{
+		hash:      sha256.Sum256(data),
+		list:      task.NewStoreInOrder(list, fileOrder).DayList(),
+		conflicts: conflicts,
+	}
+}
+
+func rememberedFile(fileName string) (fileState, bool) {
+	fileStatesMu.Lock()
+	defer fileStatesMu.Unlock()
+	state, ok := fileStates[fileStateKey(fileName)]
+	return state, ok
+}
+
+// mergeChanges merges the changes another program made to the file since towg read it into list. The changes are
+// detected by the hash of the content. Todos which were changed differently in list and in the file are returned as
+// conflicts. A file with unresolved conflicts is not changed at all, since saving would drop one of the versions.
+func mergeChanges(list task.DayList, fileName string) (task.DayList, []task.Conflict, error) {
+	state, ok := rememberedFile(fileName)
+	if !ok {
+		return list, nil, nil
+	}
+	if len(state.conflicts) > 0 {
+		return list, nil, unresolvedConflictsError(fileName, state.conflicts)
+	}
+
+	data, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return list, nil, nil
+	} else if err != nil {
+		return list, nil, fmt.Errorf("Error while reading %s: %s", fileName, err)
+	}
+	if sha256.Sum256(data) == state.hash {
+		return list, nil, nil
+	}
+
+	theirs, conflictLines, err := parseDataConflicts(bytes.NewReader(data), time.Time{})
+	if err != nil {
+		return list, nil, fmt.Errorf("%s was changed by another program and can not be merged: %s", fileName, err)
+	}
+	if len(conflictLines) > 0 {
+		return list, nil, unresolvedConflictsError(fileName, conflictLines)
+	}
+	merged, conflicts := task.Merge(state.list, list, theirs, fileOrder)
+	return merged, conflicts, nil
+}
+
+// writeConflicts writes the conflicts of the day with the given date between conflict markers
+func writeConflicts(w io.Writer, date time.Time, conflicts []task.Conflict) {
+	for _, c := range conflicts {
+		if c.Date.Format(parse.Timeformat) != date.Format(parse.Timeformat) {
+			continue
+		}
+		fmt.Fprintln(w, conflictStart)
+		if c.Ours != nil {
+			fmt.Fprintln(w, c.Ours.String()+"  ")
+		}
+		fmt.Fprintln(w, conflictSeparator)
+		if c.Theirs != nil {
+			fmt.Fprintln(w, c.Theirs.String()+"  ")
+		}
+		fmt.Fprintln(w, conflictEnd)
+	}
+}
+
+func unresolvedConflictsError(fileName string, lines []int) error {
+	return fmt.Errorf("%s has unresolved conflicts in line %s. Keep one version of each todo between the "+
+		"markers and remove the markers in an editor first", fileName, joinLines(lines))
+}
+
+// conflictsError is returned by save if the list was saved but some todos could not be merged with the changes
+// another program made to the file
+type conflictsError struct {
+	fileName string
+	lines    []int
+}
+
+func (e conflictsError) Error() string {
+	return fmt.Sprintf("%s was changed by another program in the meantime. Todos which were changed there as well "+
+		"are marked as conflicts in line %s", e.fileName, joinLines(e.lines))
+}
+
+// saved returns true if the file was written despite err, which happens if todos could not be merged or the file
+// could not be committed in git mode
+func saved(err error) bool {
+	switch err.(type) {
+	case nil, conflictsError, commitError:
+		return true
+	}
+	return false
+}
+
+// warnConflicts tells on stderr if the file has unresolved conflicts, which prevent changing it
+func warnConflicts(fileName string) {
+	if state, ok := rememberedFile(fileName); ok && len(state.conflicts) > 0 {
+		fmt.Fprintln(os.Stderr, unresolvedConflictsError(fileName, state.conflicts))
+	}
+}
+
+func joinLines(lines []int) string {
+	s := make([]string, len(lines))
+	for i, line := range lines {
+		s[i] = strconv.Itoa(line)
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/FChris/towg/task"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func editCommand() cli.Command {
+	return cli.Command{
+		Name:  "edit",
+		Usage: "changes the text of the selected todo in the list of todos for the given date, keeping its status",
+		Flags: append([]cli.Flag{
+			fileFlag(),
+			dateFlag(),
+			cli.StringFlag{
+				Name:  "text, t",
+				Usage: "new text of the todo, which may set priority, dates, projects and contexts like the text of add",
+			},
+			cli.BoolFlag{
+				Name:  "editor, e",
+				Usage: "edit the text in $VISUAL or $EDITOR instead of giving it with --text",
+			},
+		}, todoFlags("is edited")...),
+		Action: func(c *cli.Context) error {
+			fileName := c.String("file")
+			if fileName == "" {
+				fileName = fileNameDefault
+			}
+			if c.IsSet("text") == c.Bool("editor") {
+				err := fmt.Errorf("Give the new text either with --text or --editor")
+				fmt.Println(err)
+				return err
+			}
+
+			list, err := loadList(fileName)
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+			selected, err := selectTodos(c, list, fileName)
+			if err == nil && len(selected) > 1 {
+				err = fmt.Errorf("edit changes one todo at a time")
+			}
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+			s := selected[0]
+
+			text := c.String("text")
+			if c.Bool("editor") {
+				// The file is not locked while the editor is open, so the todo is looked up again afterwards
+				if text, err = editText(s.todo.Text()); err != nil {
+					fmt.Println(err)
+					return err
+				}
+			}
+			text = strings.TrimSpace(text)
+			if text == s.todo.Text() {
+				fmt.Println("The todo was not changed")
+				return nil
+			}
+
+			var edited task.Todo
+			err = updateList(fileName, func(list task.DayList) (task.DayList, string, error) {
+				ind := indexOfTodo(list, s.date, s.todo.Text())
+				if ind < 0 {
+					return list, "", fmt.Errorf("The todo %q was changed in the meantime", s.todo.Text())
+				}
+				list, err := editTodo(list, s.date, ind, text)
+				if err != nil {
+					return list, "", err
+				}
+				edited = task.ParseTodo(text, s.todo.Complete)
+				return list, changeMessage("edit", s.todo, s.date, edited.Description), nil
+			})
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+
+			// Keep the number of the todo valid in the last view
+			if !c.IsSet("date") {
+				if v, err := loadView(fileName); err == nil && v != nil {
+					v.edit(s.number, edited.Text())
+					v.save(fileName)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// editText lets the user change the text in an editor and returns the result. The editor is taken from $VISUAL or
+// $EDITOR and vi is used if neither is set.
+func editText(text string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	file, err := ioutil.TempFile("", "towg-edit-*.md")
+	if err != nil {
+		return "", fmt.Errorf("Error while creating temporary file: %s", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(text + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("Error while writing temporary file: %s", err)
+	}
+
+	// The editor may be given with arguments, like "code --wait"
+	args := append(strings.Fields(editor), file.Name())
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err = cmd.Run(); err != nil {
+		return "", fmt.Errorf("Error while running %s: %s", editor, err)
+	}
+
+	data, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		return "", fmt.Errorf("Error while reading temporary file: %s", err)
+	}
+	return string(data), nil
+}
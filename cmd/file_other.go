@@ -0,0 +1,40 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockTimeout is how long lockFile waits for another towg process before giving up
+const lockTimeout = 10 * time.Second
+
+// lockFile takes an exclusive lock for the todo file by creating the lock file, waiting while another towg process
+// holds it. The returned function releases the lock. Unlike flock the lock file stays if towg crashes, in which
+// case it has to be deleted by hand.
+func lockFile(fileName string) (unlock func(), err error) {
+	name := lockFileName(fileName)
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(name) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("Error while creating lock file: %s", err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another towg process. Delete %s if no other towg is running",
+				fileName, name)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// syncDir does nothing, since directories cannot be synced on these systems
+func syncDir(dir string) {
+}
@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/FChris/towg/parse"
+	"github.com/FChris/towg/task"
+	"github.com/urfave/cli"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// gitMode is set by the --git flag or the environment variable TOWG_GIT. Every change of a todo file is then
+// committed to the git repository the file is in, which is created if necessary.
+var gitMode bool
+
+func gitFlag() cli.Flag {
+	return cli.BoolFlag{
+		Name:   "git",
+		EnvVar: "TOWG_GIT",
+		Usage:  "commit every change of the todo file to the git repository it is in. 'towg log' shows the history",
+	}
+}
+
+// changeMessage describes the change of a todo for the commit of git mode, like
+// `switch: "Todo 25" on 17.07.17 -> done`. The result of the change is left out if it is empty.
+func changeMessage(command string, todo task.Todo, date time.Time, result string) string {
+	message := command + `: "` + todo.Description + `" on ` + date.Format(parse.Timeformat)
+	if result != "" {
+		message += " -> " + result
+	}
+	return message
+}
+
+// changePattern matches a line of a commit message written by changeMessage. It captures the description and the
+// date of the todo.
+var changePattern = regexp.MustCompile(`^\w+: "(.*)" on (\d\d\.\d\d\.\d\d)( -> .*)?$`)
+
+// statusName is the result of switching a todo to the given status in the messages of git mode
+func statusName(complete bool) string {
+	if complete {
+		return "done"
+	}
+	return "open"
+}
+
+// joinMessages combines the messages of several changes into a single commit message
+func joinMessages(command string, messages []string) string {
+	switch len(messages) {
+	case 0:
+		return ""
+	case 1:
+		return messages[0]
+	}
+	return fmt.Sprintf("%s: %d changes\n\n%s", command, len(messages), strings.Join(messages, "\n"))
+}
+
+// commitError is returned if the todo file was saved but could not be committed in git mode
+type commitError struct {
+	err error
+}
+
+func (e commitError) Error() string {
+	return "Saved, but " + e.err.Error()
+}
+
+// commitChange commits the todo file with the message if git mode is enabled and the file was changed
+func commitChange(fileName, message string) error {
+	if !gitMode || message == "" {
+		return nil
+	}
+	dir, base, err := gitPath(fileName)
+	if err != nil {
+		return commitError{err}
+	}
+
+	if _, err = runGit(dir, "rev-parse", "--git-dir"); err != nil {
+		if _, err = runGit(dir, "init", "--quiet"); err != nil {
+			return commitError{err}
+		}
+		// The backup, lock and view next to the todo file are no history
+		exclude := filepath.Join(dir, ".git", "info", "exclude")
+		if err = appendLine(exclude, "."+base+".*"); err != nil {
+			return commitError{err}
+		}
+	}
+	status, err := runGit(dir, "status", "--porcelain", "--", base)
+	if err != nil {
+		return commitError{err}
+	}
+	if status == "" {
+		return nil
+	}
+	if _, err = runGit(dir, "add", "--", base); err != nil {
+		return commitError{err}
+	}
+	// Only the todo file is committed, even if other changes are staged
+	if _, err = runGit(dir, "commit", "--quiet", "--message", message, "--", base); err != nil {
+		return commitError{err}
+	}
+	return nil
+}
+
+func appendLine(fileName, line string) error {
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("Error while opening %s: %s", fileName, err)
+	}
+	if _, err = file.WriteString(line + "\n"); err != nil {
+		file.Close()
+		return fmt.Errorf("Error while writing %s: %s", fileName, err)
+	}
+	return file.Close()
+}
+
+// gitPath returns the directory of the file, in which git is run, and the name of the file
+func gitPath(fileName string) (dir, base string, err error) {
+	abs, err := filepath.Abs(fileName)
+	if err != nil {
+		return "", "", fmt.Errorf("Error while locating %s: %s", fileName, err)
+	}
+	return filepath.Dir(abs), filepath.Base(abs), nil
+}
+
+// runGit runs git in the directory and returns its output
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if len(out) > 0 {
+			err = fmt.Errorf("%s", strings.TrimSpace(string(out)))
+		}
+		return "", fmt.Errorf("Error while running git %s: %s", args[0], err)
+	}
+	return string(out), nil
+}
+
+func logCommand() cli.Command {
+	return cli.Command{
+		Name:  "log",
+		Usage: "shows the changes committed in git mode, for the whole file or for a single todo",
+		Flags: []cli.Flag{
+			fileFlag(),
+			dateFlag(),
+			cli.IntFlag{
+				Name: "number, n",
+				Usage: "number of the todo whose history is shown, as shown by print." +
+					"\n\tIf no date is given the number refers to the list printed last",
+			},
+			cli.StringFlag{
+				Name:  "text, t",
+				Usage: "only show changes of todos whose description contains the text",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			fileName := c.String("file")
+			if fileName == "" {
+				fileName = fileNameDefault
+			}
+
+			filter := logFilter{text: c.String("text")}
+			if c.IsSet("number") {
+				list, err := loadList(fileName)
+				if err != nil {
+					fmt.Println(err)
+					return err
+				}
+				date, ind, err := selectTodo(c, list, fileName, c.Int("number"))
+				if err != nil {
+					fmt.Println(err)
+					return err
+				}
+				filter.desc = list.DayByDate(date).Todos[ind].Description
+				filter.dated, filter.from, filter.to = true, date, date
+			} else if c.IsSet("date") {
+				from, to, err := periodBounds(c.String("date"))
+				if err != nil {
+					fmt.Println(err)
+					return err
+				}
+				filter.dated, filter.from, filter.to = true, from, to
+			}
+
+			dir, base, err := gitPath(fileName)
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+			// Dates are shown like the dates of the todo file. Every commit starts with a record separator and its
+			// summary is separated from its message by a unit separator.
+			out, err := runGit(dir, "log", "--format=%x1e%h %ad %s%x1f%B", "--date=format:%d.%m.%y %H:%M", "--",
+				base)
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+			found := false
+			for _, commit := range strings.Split(out, "\x1e")[1:] {
+				parts := strings.SplitN(commit, "\x1f", 2)
+				if len(parts) == 2 && filter.matches(parts[1]) {
+					fmt.Println(parts[0])
+					found = true
+				}
+			}
+			if !found {
+				fmt.Println("No changes found")
+			}
+			return nil
+		},
+	}
+}
+
+// logFilter selects the commits shown by log by the changes of todos in their messages
+type logFilter struct {
+	// desc is the description of the todo if the history of a single todo is shown
+	desc string
+	// text is a text the description contains
+	text string
+	// dated is true if only changes of todos on the days from to to are shown
+	dated    bool
+	from, to time.Time
+}
+
+// matches returns true if the commit message has a change of a todo selected by the filter. Without filter all
+// commits match.
+func (f logFilter) matches(message string) bool {
+	if f == (logFilter{}) {
+		return true
+	}
+	for _, line := range strings.Split(message, "\n") {
+		m := changePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		date, err := time.Parse(parse.Timeformat, m[2])
+		if err != nil {
+			continue
+		}
+		if (f.desc == "" || m[1] == f.desc) && strings.Contains(m[1], f.text) &&
+			(!f.dated || inTimeSpan(f.from, f.to, date)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/FChris/towg/task"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLogFilter_Matches(t *testing.T) {
+	date := time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC)
+	milk := changeMessage("switch", task.Todo{Description: "Buy milk"}, date, statusName(true))
+	oatMilk := changeMessage("add", task.Todo{Description: "Buy oat milk"}, date.AddDate(0, 0, 1), "")
+	joined := joinMessages("shell", []string{oatMilk, milk})
+	assert.Equal(t, `switch: "Buy milk" on 17.07.17 -> done`, milk, "Change message has the wrong form")
+
+	exact := logFilter{desc: "Buy milk", dated: true, from: date, to: date}
+	assert.Equal(t, true, exact.matches(milk), "Change of the todo does not match")
+	assert.Equal(t, true, exact.matches(joined), "Change of the todo among others does not match")
+	assert.Equal(t, false, exact.matches(oatMilk), "Change of a todo containing the description matches")
+	assert.Equal(t, false, exact.matches("Buy milk"), "Message which is no change matches")
+
+	tomorrow := logFilter{desc: "Buy milk", dated: true, from: date.AddDate(0, 0, 1), to: date.AddDate(0, 0, 1)}
+	assert.Equal(t, false, tomorrow.matches(milk), "Change of the todo on another day matches")
+
+	text := logFilter{text: "milk", dated: true, from: date.AddDate(0, 0, 1), to: date.AddDate(0, 0, 7)}
+	assert.Equal(t, true, text.matches(oatMilk), "Change of a todo containing the text does not match")
+	assert.Equal(t, false, text.matches(milk), "Change of a todo before the period matches")
+
+	assert.Equal(t, true, logFilter{}.matches("merge: 3 todos"), "Commit does not match without filter")
+}
@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/FChris/towg/parse"
+	"github.com/FChris/towg/task"
+	"github.com/urfave/cli"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policies for todos which differ between the merged files
+const (
+	mergeNewest = "newest"
+	mergeDone   = "done"
+	mergeAsk    = "ask"
+)
+
+func mergeCommand() cli.Command {
+	return cli.Command{
+		Name:      "merge",
+		Usage:     "adds the todos of other todo files to the todo file",
+		ArgsUsage: "FILE...",
+		Flags: []cli.Flag{
+			fileFlag(),
+			cli.StringFlag{
+				Name: "policy, p",
+				Usage: "what to do if a todo differs between the files, like being done in one of them. " +
+					"\n\t'newest' keeps the todo of the file changed last, 'done' keeps a done todo and 'ask' asks. " +
+					"\n\tDefault is 'newest'",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			fileName := c.String("file")
+			if fileName == "" {
+				fileName = fileNameDefault
+			}
+			if c.NArg() == 0 {
+				err := fmt.Errorf("No files to merge given")
+				fmt.Println(err)
+				return err
+			}
+
+			policy, err := mergePolicyByName(c.String("policy"), os.Stdin, os.Stdout)
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+			var sources []mergeSource
+			for _, name := range c.Args() {
+				source, err := readMergeSource(name)
+				if err != nil {
+					fmt.Println(err)
+					return err
+				}
+				sources = append(sources, source)
+			}
+			if c.String("policy") == mergeAsk {
+				// The file is not locked while the user decides, so other towg processes are not blocked
+				if policy, err = askMergeVersions(fileName, sources, policy); err != nil {
+					fmt.Println(err)
+					return err
+				}
+			}
+
+			var report []string
+			err = updateList(fileName, func(list task.DayList) (task.DayList, string, error) {
+				merged, changes, err := mergeSources(mergeTarget(fileName, list), sources, policy)
+				if err != nil {
+					return list, "", err
+				}
+				report = changes
+				message := "merge: " + strings.Join(c.Args(), ", ")
+				if len(changes) > 0 {
+					message += "\n\n" + strings.Join(changes, "\n")
+				}
+				return merged, message, nil
+			})
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+
+			for _, change := range report {
+				fmt.Println(change)
+			}
+			fmt.Printf("Merged %d files into %s with %d changes\n", len(sources), fileName, len(report))
+			return nil
+		},
+	}
+}
+
+// mergeSource is the list of a merged file
+type mergeSource struct {
+	fileName string
+	list     task.DayList
+	modTime  time.Time
+}
+
+// mergeVersion is a todo as it is found in one of the merged files
+type mergeVersion struct {
+	todo   task.Todo
+	source *mergeSource
+}
+
+// mergePolicy chooses one of the differing versions of the todo with the given date
+type mergePolicy func(date time.Time, versions []mergeVersion) (mergeVersion, error)
+
+// mergeTarget returns the list of the file the sources are merged into
+func mergeTarget(fileName string, list task.DayList) mergeSource {
+	target := mergeSource{fileName: fileName, list: list}
+	if info, err := os.Stat(fileName); err == nil {
+		target.modTime = info.ModTime()
+	}
+	return target
+}
+
+// mergeKey identifies a todo by its date and text across the merged files
+func mergeKey(date time.Time, text string) string {
+	return date.Format(task.DateFormat) + "\n" + text
+}
+
+func readMergeSource(fileName string) (mergeSource, error) {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return mergeSource{}, fmt.Errorf("Error while opening file: %s", err)
+	}
+	list, err := parseFromFile(fileName)
+	if err != nil {
+		return mergeSource{}, err
+	}
+	return mergeSource{fileName: fileName, list: list, modTime: info.ModTime()}, nil
+}
+
+// mergeSources adds the todos of the sources to the target. A todo with the same date and text as a todo of
+// another file but with a different status is chosen by the policy. The changes made to the
+// target are described in the order the todos appear in the files.
+func mergeSources(target mergeSource, sources []mergeSource, policy mergePolicy) (task.DayList, []string, error) {
+	all := append([]mergeSource{target}, sources...)
+	store := task.NewStoreInOrder(target.list, fileOrder)
+
+	versions := map[string][]mergeVersion{}
+	var dates []time.Time
+	var keys []string
+	for i := range all {
+		for _, day := range all[i].list {
+			store.Insert(day.Date, nil)
+			for _, todo := range day.Todos {
+				key := mergeKey(day.Date, todo.Text())
+				if _, ok := versions[key]; !ok {
+					dates, keys = append(dates, day.Date), append(keys, key)
+				}
+				versions[key] = addMergeVersion(versions[key], mergeVersion{todo: todo, source: &all[i]})
+			}
+		}
+	}
+
+	var changes []string
+	for i, key := range keys {
+		date := dates[i]
+		chosen := versions[key][0]
+		if len(versions[key]) > 1 {
+			var err error
+			if chosen, err = policy(date, versions[key]); err != nil {
+				return target.list, nil, err
+			}
+		}
+		if chosen.source == &all[0] {
+			continue
+		}
+
+		old, existed := findTodo(target.list, date, chosen.todo.Text())
+		store.InsertTodo(date, chosen.todo)
+		change := fmt.Sprintf("Added %s %s (%s)", date.Format(parse.Timeformat), chosen.todo, chosen.source.fileName)
+		if existed {
+			change = fmt.Sprintf("Changed %s %s to %s (%s)", date.Format(parse.Timeformat), old, chosen.todo,
+				chosen.source.fileName)
+		}
+		changes = append(changes, change)
+	}
+
+	return store.DayList(), changes, nil
+}
+
+// addMergeVersion adds the version to the versions unless an equal todo is part of them already. Versions of the
+// target come first, so an unchanged todo is attributed to the target.
+func addMergeVersion(versions []mergeVersion, version mergeVersion) []mergeVersion {
+	for _, v := range versions {
+		if v.todo == version.todo {
+			return versions
+		}
+	}
+	return append(versions, version)
+}
+
+// findTodo returns the todo with the text from the day with the given date
+func findTodo(list task.DayList, date time.Time, text string) (task.Todo, bool) {
+	for _, todo := range list.DayByDate(date).Todos {
+		if todo.Text() == text {
+			return todo, true
+		}
+	}
+	return task.Todo{}, false
+}
+
+// mergePolicyByName returns the policy with the given name. The ask policy reads the answers from in and writes
+// the questions to out.
+func mergePolicyByName(name string, in io.Reader, out io.Writer) (mergePolicy, error) {
+	switch name {
+	case "", mergeNewest:
+		return newestVersion, nil
+	case mergeDone:
+		return func(date time.Time, versions []mergeVersion) (mergeVersion, error) {
+			var done []mergeVersion
+			for _, v := range versions {
+				if v.todo.Complete {
+					done = append(done, v)
+				}
+			}
+			if len(done) > 0 {
+				versions = done
+			}
+			return newestVersion(date, versions)
+		}, nil
+	case mergeAsk:
+		answers := bufio.NewScanner(in)
+		return func(date time.Time, versions []mergeVersion) (mergeVersion, error) {
+			return askVersion(answers, out, date, versions)
+		}, nil
+	}
+	return nil, fmt.Errorf("Unknown policy %q, expected one of %s, %s or %s", name, mergeNewest, mergeDone, mergeAsk)
+}
+
+// askMergeVersions merges the sources into the current list of the file without saving it, so that the policy asks
+// for all differing todos before the file is locked. It returns a policy which chooses the answers again while the
+// file is locked. It fails if a todo was changed in the meantime, since the user was asked about other versions.
+func askMergeVersions(fileName string, sources []mergeSource, ask mergePolicy) (mergePolicy, error) {
+	list, err := loadListOrEmpty(fileName)
+	if err != nil {
+		return nil, err
+	}
+	// The versions of the todos with the version chosen last
+	answers := map[string][]task.Todo{}
+	_, _, err = mergeSources(mergeTarget(fileName, list), sources,
+		func(date time.Time, versions []mergeVersion) (mergeVersion, error) {
+			chosen, err := ask(date, versions)
+			if err == nil {
+				answers[mergeKey(date, chosen.todo.Text())] = append(versionTodos(versions), chosen.todo)
+			}
+			return chosen, err
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return func(date time.Time, versions []mergeVersion) (mergeVersion, error) {
+		answer, ok := answers[mergeKey(date, versions[0].todo.Text())]
+		if ok && sameTodos(versionTodos(versions), answer[:len(answer)-1]) {
+			for _, v := range versions {
+				if v.todo == answer[len(answer)-1] {
+					return v, nil
+				}
+			}
+		}
+		return mergeVersion{}, fmt.Errorf("%q on %s was changed in %s while merging. Merge again",
+			versions[0].todo.Text(), date.Format(parse.Timeformat), fileName)
+	}, nil
+}
+
+// versionTodos returns the todos of the versions
+func versionTodos(versions []mergeVersion) []task.Todo {
+	todos := make([]task.Todo, len(versions))
+	for i, v := range versions {
+		todos[i] = v.todo
+	}
+	return todos
+}
+
+// sameTodos returns true if both lists have equal todos in the same order
+func sameTodos(a, b []task.Todo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// newestVersion chooses the version from the file changed last. Of equally old files the one given last wins.
+func newestVersion(date time.Time, versions []mergeVersion) (mergeVersion, error) {
+	newest := versions[0]
+	for _, v := range versions[1:] {
+		if !v.source.modTime.Before(newest.source.modTime) {
+			newest = v
+		}
+	}
+	return newest, nil
+}
+
+// askVersion lets the user choose one of the versions
+func askVersion(answers *bufio.Scanner, out io.Writer, date time.Time, versions []mergeVersion) (mergeVersion, error) {
+	fmt.Fprintf(out, "%q on %s differs between the files:\n", versions[0].todo.Text(),
+		date.Format(parse.Timeformat))
+	for i, v := range versions {
+		fmt.Fprintf(out, "  %d) %s (%s)\n", i+1, v.todo, v.source.fileName)
+	}
+	for {
+		fmt.Fprintf(out, "Keep which version? [1-%d]: ", len(versions))
+		if !answers.Scan() {
+			if answers.Err() != nil {
+				return mergeVersion{}, answers.Err()
+			}
+			return mergeVersion{}, fmt.Errorf("Merge aborted, no version was chosen")
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(answers.Text()))
+		if err == nil && n >= 1 && n <= len(versions) {
+			return versions[n-1], nil
+		}
+	}
+}
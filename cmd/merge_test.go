@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"github.com/FChris/towg/task"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAskMergeVersions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "towg")
+	assert.Equal(t, nil, err, "Error for creating the directory is not nil")
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "team.todo")
+	other := filepath.Join(dir, "alice.todo")
+	write := func(name, data string) {
+		assert.Equal(t, nil, ioutil.WriteFile(name, []byte(data), 0600), "Error for writing the file is not nil")
+	}
+	write(fileName, "\n# 17.07.17\n\n- [ ] Todo 1  \n- [ ] Todo 2  \n")
+	write(other, "\n# 17.07.17\n\n- [x] Todo 1  \n- [x] Todo 2  \n- [x] Todo 3  \n")
+	source, err := readMergeSource(other)
+	assert.Equal(t, nil, err, "Error for reading the merged file is not nil")
+
+	merge := func(policy mergePolicy) error {
+		return updateList(fileName, func(list task.DayList) (task.DayList, string, error) {
+			merged, _, err := mergeSources(mergeTarget(fileName, list), []mergeSource{source}, policy)
+			return merged, "", err
+		})
+	}
+
+	ask, err := mergePolicyByName(mergeAsk, strings.NewReader("2\n1\n"), ioutil.Discard)
+	assert.Equal(t, nil, err, "Error for the ask policy is not nil")
+	policy, err := askMergeVersions(fileName, []mergeSource{source}, ask)
+	assert.Equal(t, nil, err, "Error for asking is not nil")
+	assert.Equal(t, nil, merge(policy), "Error for merging the answers is not nil")
+	list, err := loadList(fileName)
+	assert.Equal(t, nil, err, "Error for loading the file is not nil")
+	assert.Equal(
+		t,
+		task.TodoList{{Description: "Todo 1", Complete: true}, {Description: "Todo 3", Complete: true},
+			{Description: "Todo 2"}},
+		list[0].Todos,
+		"Answers were not applied")
+
+	write(fileName, "\n# 17.07.17\n\n- [ ] Todo 1  \n- [ ] Todo 2  \n")
+	ask, err = mergePolicyByName(mergeAsk, strings.NewReader("2\n2\n"), ioutil.Discard)
+	assert.Equal(t, nil, err, "Error for the ask policy is not nil")
+	policy, err = askMergeVersions(fileName, []mergeSource{source}, ask)
+	assert.Equal(t, nil, err, "Error for asking is not nil")
+	// Another program adds a differing todo while the user decides
+	write(fileName, "\n# 17.07.17\n\n- [ ] Todo 1  \n- [ ] Todo 2  \n- [ ] Todo 3  \n")
+	assert.NotEqual(t, nil, merge(policy), "Todo changed while asking is merged without asking")
+	data, err := ioutil.ReadFile(fileName)
+	assert.Equal(t, nil, err, "Error for reading the file is not nil")
+	assert.Equal(t, "\n# 17.07.17\n\n- [ ] Todo 1  \n- [ ] Todo 2  \n- [ ] Todo 3  \n", string(data),
+		"Failed merge changed the file")
+}
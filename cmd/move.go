@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/FChris/towg/parse"
+	"github.com/FChris/towg/task"
+	"github.com/urfave/cli"
+)
+
+func moveCommand() cli.Command {
+	return cli.Command{
+		Name:  "move",
+		Usage: "moves the selected todo to the position of another todo of the same day. Needs the manual order",
+		Flags: append([]cli.Flag{
+			fileFlag(),
+			dateFlag(),
+			cli.IntFlag{
+				Name:  "to",
+				Usage: "number of the todo whose position the todo takes, as shown by print",
+			},
+		}, todoFlags("is moved")...),
+		Action: func(c *cli.Context) error {
+			if !c.IsSet("to") {
+				err := fmt.Errorf("No position given. Give the number of the todo whose position it takes with --to")
+				fmt.Println(err)
+				return err
+			}
+			return moveTodo(c, "move", func(s selectedTodo, numbered []selectedTodo, fromView bool,
+				day task.Day) (selectedTodo, error) {
+				target, err := numberedTodo(numbered, fromView, c.Int("to"))
+				if err != nil {
+					return target, err
+				}
+				if target.missing {
+					return target, fmt.Errorf("Todo %d of the list printed last no longer exists. Print the list again",
+						target.number)
+				}
+				if !target.date.Equal(s.date) {
+					return target, fmt.Errorf("Todo %d is on another day. Use redate to move a todo to another day",
+						target.number)
+				}
+				return target, nil
+			})
+		},
+	}
+}
+
+func upCommand() cli.Command {
+	return cli.Command{
+		Name:   "up",
+		Usage:  "moves the selected todo one position up within its day. Needs the manual order",
+		Flags:  append([]cli.Flag{fileFlag(), dateFlag()}, todoFlags("is moved")...),
+		Action: func(c *cli.Context) error { return moveTodo(c, "up", neighbour(-1)) },
+	}
+}
+
+func downCommand() cli.Command {
+	return cli.Command{
+		Name:   "down",
+		Usage:  "moves the selected todo one position down within its day. Needs the manual order",
+		Flags:  append([]cli.Flag{fileFlag(), dateFlag()}, todoFlags("is moved")...),
+		Action: func(c *cli.Context) error { return moveTodo(c, "down", neighbour(1)) },
+	}
+}
+
+// movePosition returns the todo of the same day whose position the selected todo takes
+type movePosition func(s selectedTodo, numbered []selectedTodo, fromView bool, day task.Day) (selectedTodo, error)
+
+// neighbour returns the todo which is offset positions away from the selected todo within its day. Its number is
+// zero if it is not one of the numbered todos.
+func neighbour(offset int) movePosition {
+	return func(s selectedTodo, numbered []selectedTodo, fromView bool, day task.Day) (selectedTodo, error) {
+		ind := indexOfTodo(task.DayList{day}, day.Date, s.todo.Text()) + offset
+		if ind < 0 || ind >= len(day.Todos) {
+			place := "first"
+			if offset > 0 {
+				place = "last"
+			}
+			return s, fmt.Errorf("%q already is the %s todo of %s", s.todo.Description, place,
+				s.date.Format(parse.Timeformat))
+		}
+		target := selectedTodo{date: s.date, todo: day.Todos[ind]}
+		for _, n := range numbered {
+			if n.date.Equal(s.date) && n.todo.Text() == target.todo.Text() {
+				target.number = n.number
+			}
+		}
+		return target, nil
+	}
+}
+
+// moveTodo moves the selected todo to the position of the todo returned by position and keeps the numbers of the
+// list printed last in the new order
+func moveTodo(c *cli.Context, command string, position movePosition) error {
+	fileName := c.String("file")
+	if fileName == "" {
+		fileName = fileNameDefault
+	}
+	if !fileOrder.Todos.Manual() {
+		err := fmt.Errorf("%s needs the manual order. Use --order manual or set TOWG_ORDER=manual", command)
+		fmt.Println(err)
+		return err
+	}
+
+	var from, to int
+	err := updateList(fileName, func(list task.DayList) (task.DayList, string, error) {
+		selected, err := selectTodos(c, list, fileName)
+		if err == nil && len(selected) > 1 {
+			err = fmt.Errorf("%s moves one todo at a time", command)
+		}
+		if err != nil {
+			return list, "", err
+		}
+		s := selected[0]
+		numbered, fromView, err := numberedTodos(c, list, fileName)
+		if err != nil {
+			return list, "", err
+		}
+
+		day := list.DayByDate(s.date)
+		target, err := position(s, numbered, fromView, day)
+		if err != nil {
+			return list, "", err
+		}
+		ind := indexOfTodo(list, s.date, s.todo.Text())
+		newInd := indexOfTodo(list, s.date, target.todo.Text())
+		if ind == newInd {
+			return list, "", nil
+		}
+		if err = day.Todos.Move(ind, newInd); err != nil {
+			return list, "", err
+		}
+		list.SetDay(day)
+
+		from, to = s.number, target.number
+		return list, changeMessage(command, s.todo, s.date, fmt.Sprintf("position %d", newInd+1)), nil
+	})
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	if from > 0 && to > 0 && !c.IsSet("date") {
+		if v, err := loadView(fileName); err == nil && v != nil {
+			v.move(from, to)
+			v.save(fileName)
+		}
+	}
+	return nil
+}
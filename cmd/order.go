@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/FChris/towg/task"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// config holds the settings of the config file, which are used if neither the flag nor its environment variable is
+// given
+type config struct {
+	Order         string `yaml:"order"`
+	DayOrder      string `yaml:"day-order"`
+	PrintOrder    string `yaml:"print-order"`
+	PrintDayOrder string `yaml:"print-day-order"`
+}
+
+// configRead is set once the config file was read. The shell runs the app for every command, which must neither
+// read the file again nor override the orders towg was started with.
+var configRead bool
+
+// fileOrder is the order the todo file is read and written in
+var fileOrder = task.DefaultOrder
+
+// printTodoOrder and printDayOrder are the orders of print. If they are nil print uses the order of the file.
+var (
+	printTodoOrder *task.TodoOrder
+	printDayOrder  *task.DayOrder
+)
+
+func orderFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "order",
+			EnvVar: "TOWG_ORDER",
+			Usage: "order of the todos of a day in the file. A list of the keys status, priority, alphabetic, created" +
+				"\n\tand tag like 'priority,-created', where '-' sorts the other way round. 'manual' keeps the order" +
+				"\n\tthey are written in, which move, up and down change. Default is 'status'",
+		},
+		cli.StringFlag{
+			Name:   "day-order",
+			EnvVar: "TOWG_DAY_ORDER",
+			Usage:  "order of the days in the file, 'newest' or 'oldest' first. Default is 'newest'",
+		},
+		cli.StringFlag{
+			Name:   "print-order",
+			EnvVar: "TOWG_PRINT_ORDER",
+			Usage:  "order of the todos of a day printed by print, like --order. Default is the order of the file",
+		},
+		cli.StringFlag{
+			Name:   "print-day-order",
+			EnvVar: "TOWG_PRINT_DAY_ORDER",
+			Usage:  "order of the days printed by print, like --day-order. Default is the order of the file",
+		},
+	}
+}
+
+// configFileName returns the name of the config file, which is given by TOWG_CONFIG or is config.yml in the towg
+// directory of the user's config directory
+func configFileName() string {
+	if name := os.Getenv("TOWG_CONFIG"); name != "" {
+		return name
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "towg", "config.yml")
+}
+
+// readConfig reads the config file. A missing file is an empty config.
+func readConfig(fileName string) (config, error) {
+	var conf config
+	if fileName == "" {
+		return conf, nil
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return conf, nil
+	}
+	if err != nil {
+		return conf, fmt.Errorf("Error while reading config: %s", err)
+	}
+	if err = yaml.UnmarshalStrict(data, &conf); err != nil {
+		return conf, fmt.Errorf("Error while reading config %s: %s", fileName, err)
+	}
+	return conf, nil
+}
+
+// setOrders sets the orders of the file and of print from the flags, their environment variables or the config
+// file. Orders which are given nowhere keep their value.
+func setOrders(c *cli.Context) error {
+	var conf config
+	if !configRead {
+		var err error
+		if conf, err = readConfig(configFileName()); err != nil {
+			return err
+		}
+		configRead = true
+	}
+	setting := func(name, configured string) string {
+		if value := c.String(name); value != "" {
+			return value
+		}
+		return configured
+	}
+
+	if name := setting("order", conf.Order); name != "" {
+		order, err := task.ParseTodoOrder(name)
+		if err != nil {
+			return err
+		}
+		fileOrder.Todos = order
+	}
+	if name := setting("day-order", conf.DayOrder); name != "" {
+		order, err := task.ParseDayOrder(name)
+		if err != nil {
+			return err
+		}
+		fileOrder.Days = order
+	}
+	if name := setting("print-order", conf.PrintOrder); name != "" {
+		order, err := task.ParseTodoOrder(name)
+		if err != nil {
+			return err
+		}
+		printTodoOrder = &order
+	}
+	if name := setting("print-day-order", conf.PrintDayOrder); name != "" {
+		order, err := task.ParseDayOrder(name)
+		if err != nil {
+			return err
+		}
+		printDayOrder = &order
+	}
+	return nil
+}
+
+// printPeriod returns the days of the period in the order of print. The todos are not shared with list.
+func printPeriod(list task.DayList, period string) (task.DayList, error) {
+	periodList, err := dayListByPeriod(list, period)
+	if err != nil {
+		return periodList, err
+	}
+	if printDayOrder != nil {
+		printDayOrder.Sort(periodList)
+	}
+	for i, day := range periodList {
+		todos := make(task.TodoList, len(day.Todos))
+		copy(todos, day.Todos)
+		if printTodoOrder != nil {
+			printTodoOrder.Sort(todos)
+		}
+		periodList[i].Todos = todos
+	}
+	return periodList, nil
+}
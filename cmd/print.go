@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/FChris/towg/parse"
+	"github.com/FChris/towg/task"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+const defaultPrintFormat = "detailed"
+
+// printFormats are the named presets for the --format flag of print. They are executed once for the whole list
+// of printDays.
+var printFormats = map[string]string{
+	"plain": `{{range .}}
+{{.DateString}}
+{{range .Todos}}{{.String}}
+{{end}}{{end}}`,
+
+	"detailed": `{{range .}}
+{{color "heading" .Label}} {{.DateString}}  {{.Done}}/{{.Total}} done
+{{range .Todos}}{{printf "%3d" .Number}} {{highlight .Change (color .Status .String)}}
+{{end}}{{end}}`,
+
+	"compact": `{{range .}}{{range .Todos}}{{printf "%3d" .Number}} {{printf "%-14s" .Label}} {{highlight .Change (color .Status .String)}}
+{{end}}{{end}}`,
+
+	"table": `#	DATE	STATUS	PRI	DESCRIPTION
+{{range .}}{{range .Todos}}{{.Number}}	{{.DateString}}	{{.Status}}	{{.Priority}}	{{highlight .Change (color .Status .Description)}}
+{{end}}{{end}}`,
+}
+
+// ansiColors are the escape sequences used by the color template function for every status
+var ansiColors = map[string]string{
+	"heading": "\x1b[1m",
+	"done":    "\x1b[32m",
+	"overdue": "\x1b[1;31m",
+	"today":   "\x1b[33m",
+}
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiHighlight = "\x1b[7m"
+)
+
+// printDay is the data of a day for print templates
+type printDay struct {
+	Date       time.Time
+	DateString string
+	// Label is the date relative to today like "Today" or "Mon 17 Jul"
+	Label string
+	Done  int
+	Total int
+	Todos []printTodo
+}
+
+// printTodo is the data of a todo for print templates. It contains all fields and methods of task.Todo.
+type printTodo struct {
+	task.Todo
+	// Number is the position of the todo in the printed list, as used by the number flag of other commands
+	Number     int
+	Date       time.Time
+	DateString string
+	Label      string
+	// Status is "done", "overdue" for open todos of past days, "today" for open todos of today or "open"
+	Status string
+	// Change is "new" or "changed" if the todo differs from the previous output of print --watch
+	Change string
+}
+
+// printOptions configure printDayList
+type printOptions struct {
+	// format is the name of a preset or a text/template that is executed for every todo
+	format string
+	// colors enables the ANSI colors of the color and highlight template functions
+	colors bool
+	// previous is compared to the printed list to set the Change of the todos if diff is true
+	previous task.DayList
+	diff     bool
+}
+
+// printDayList writes the list to w in the format of the options
+func printDayList(w io.Writer, list task.DayList, opts printOptions) error {
+	format, colors := opts.format, opts.colors
+	if format == "" {
+		format = defaultPrintFormat
+	}
+
+	perTodo := false
+	text, ok := printFormats[format]
+	if !ok {
+		if !strings.Contains(format, "{{") {
+			return fmt.Errorf("Unknown format %q. Use one of plain, detailed, compact, table or a template", format)
+		}
+		text = format
+		perTodo = true
+	}
+
+	tmpl, err := template.New("print").Funcs(template.FuncMap{
+		"color": func(status string, s interface{}) string {
+			text := fmt.Sprint(s)
+			if code, ok := ansiColors[status]; ok && colors {
+				return code + text + ansiReset
+			}
+			return text
+		},
+		"highlight": func(change string, s interface{}) string {
+			text := fmt.Sprint(s)
+			switch {
+			case change == "":
+			case colors:
+				text = ansiHighlight + text + ansiReset
+			default:
+				text += " (" + change + ")"
+			}
+			return text
+		},
+	}).Parse(text)
+	if err != nil {
+		return fmt.Errorf("Parsing format: %s", err)
+	}
+
+	days := printDays(list, time.Now())
+	if opts.diff {
+		markChanges(days, opts.previous)
+	}
+	if !perTodo {
+		if format == "table" {
+			tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+			if err = tmpl.Execute(tw, days); err != nil {
+				return err
+			}
+			return tw.Flush()
+		}
+		return tmpl.Execute(w, days)
+	}
+
+	for _, day := range days {
+		for _, todo := range day.Todos {
+			if err = tmpl.Execute(w, todo); err != nil {
+				return err
+			}
+			if _, err = fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// printDays prepares the list for print templates with labels and states relative to now
+func printDays(list task.DayList, now time.Time) []printDay {
+	current := ignoreTime(now)
+	days := make([]printDay, 0, len(list))
+	number := 0
+	for _, day := range list {
+		date := ignoreTime(day.Date)
+		d := printDay{
+			Date:       day.Date,
+			DateString: day.Date.Format(parse.Timeformat),
+			Label:      relativeDateLabel(date, current),
+			Total:      len(day.Todos),
+		}
+
+		for _, todo := range day.Todos {
+			status := todoStatus(todo, date, current)
+			if todo.Complete {
+				d.Done++
+			}
+			number++
+			d.Todos = append(d.Todos, printTodo{
+				Todo:       todo,
+				Number:     number,
+				Date:       d.Date,
+				DateString: d.DateString,
+				Label:      d.Label,
+				Status:     status,
+			})
+		}
+		days = append(days, d)
+	}
+	return days
+}
+
+// markChanges sets the Change of all todos which are not part of the previous list or differ from it
+func markChanges(days []printDay, previous task.DayList) {
+	old := make(map[string]task.Todo)
+	for _, day := range previous {
+		for _, todo := range day.Todos {
+			old[day.Date.Format(task.DateFormat)+"\n"+todo.Text()] = todo
+		}
+	}
+
+	for i := range days {
+		for j := range days[i].Todos {
+			todo := &days[i].Todos[j]
+			prev, ok := old[todo.Date.Format(task.DateFormat)+"\n"+todo.Text()]
+			if !ok {
+				todo.Change = "new"
+			} else if prev != todo.Todo {
+				todo.Change = "changed"
+			}
+		}
+	}
+}
+
+// todoStatus returns "done", "overdue", "today" or "open" for a todo of the given date
+func todoStatus(todo task.Todo, date, current time.Time) string {
+	switch {
+	case todo.Complete:
+		return "done"
+	case date.Before(current):
+		return "overdue"
+	case date.Equal(current):
+		return "today"
+	}
+	return "open"
+}
+
+// relativeDateLabel names date relative to the current date as "Yesterday", "Today" or "Tomorrow" or otherwise
+// as "Mon 17 Jul", with the year added for other years
+func relativeDateLabel(date, current time.Time) string {
+	switch {
+	case date.Equal(current.AddDate(0, 0, -1)):
+		return "Yesterday"
+	case date.Equal(current):
+		return "Today"
+	case date.Equal(current.AddDate(0, 0, 1)):
+		return "Tomorrow"
+	case date.Year() != current.Year():
+		return date.Format("Mon 2 Jan 2006")
+	}
+	return date.Format("Mon 2 Jan")
+}
+
+// useColors reports whether print should color its output for the given --color flag value.
+// By default colors are used if stdout is a terminal and the NO_COLOR environment variable is not set.
+func useColors(flag string) (bool, error) {
+	switch flag {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "", "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		info, err := os.Stdout.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+	}
+	return false, fmt.Errorf("Unknown color mode %q. Use auto, always or never", flag)
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"github.com/FChris/towg/task"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+// printTestList returns two days of 2017, so that every todo is overdue or done and the labels name the year
+func printTestList() task.DayList {
+	date := time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC)
+	return task.DayList{
+		{Date: date, Todos: task.TodoList{{Description: "Todo A", Complete: true}, {Description: "Todo B", Priority: "A"}}},
+		{Date: date.AddDate(0, 0, 1), Todos: task.TodoList{{Description: "Todo C"}}},
+	}
+}
+
+func TestPrintDayList_Formats(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"plain", "\n17.07.17\n- [x] Todo A\n- [ ] (A) Todo B\n\n18.07.17\n- [ ] Todo C\n"},
+		{"", "\nMon 17 Jul 2017 17.07.17  1/2 done\n  1 - [x] Todo A\n  2 - [ ] (A) Todo B\n" +
+			"\nTue 18 Jul 2017 18.07.17  0/1 done\n  3 - [ ] Todo C\n"},
+		{"detailed", "\nMon 17 Jul 2017 17.07.17  1/2 done\n  1 - [x] Todo A\n  2 - [ ] (A) Todo B\n" +
+			"\nTue 18 Jul 2017 18.07.17  0/1 done\n  3 - [ ] Todo C\n"},
+		{"compact", "  1 Mon 17 Jul 2017 - [x] Todo A\n  2 Mon 17 Jul 2017 - [ ] (A) Todo B\n" +
+			"  3 Tue 18 Jul 2017 - [ ] Todo C\n"},
+		{"table", "#  DATE      STATUS   PRI  DESCRIPTION\n" +
+			"1  17.07.17  done          Todo A\n" +
+			"2  17.07.17  overdue  A    Todo B\n" +
+			"3  18.07.17  overdue       Todo C\n"},
+		{"{{.Number}} {{.Label}} {{.Status}} {{.Priority}}{{.Description}}",
+			"1 Mon 17 Jul 2017 done Todo A\n2 Mon 17 Jul 2017 overdue ATodo B\n3 Tue 18 Jul 2017 overdue Todo C\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		err := printDayList(&out, printTestList(), printOptions{format: test.format})
+		assert.Equal(t, nil, err, "Error for printing in format %q is not nil", test.format)
+		assert.Equal(t, test.expected, out.String(), "Wrong output for format %q", test.format)
+	}
+
+	var out bytes.Buffer
+	err := printDayList(&out, printTestList(), printOptions{format: "fancy"})
+	assert.NotEqual(t, nil, err, "Unknown format without template actions is not reported")
+	err = printDayList(&out, printTestList(), printOptions{format: "{{.Missing"})
+	assert.NotEqual(t, nil, err, "Broken template is not reported")
+}
+
+func TestPrintDayList_Colors(t *testing.T) {
+	var out bytes.Buffer
+	err := printDayList(&out, printTestList(), printOptions{format: "detailed", colors: true})
+	assert.Equal(t, nil, err, "Error for printing is not nil")
+	assert.Equal(
+		t,
+		"\n\x1b[1mMon 17 Jul 2017\x1b[0m 17.07.17  1/2 done\n"+
+			"  1 \x1b[32m- [x] Todo A\x1b[0m\n  2 \x1b[1;31m- [ ] (A) Todo B\x1b[0m\n"+
+			"\n\x1b[1mTue 18 Jul 2017\x1b[0m 18.07.17  0/1 done\n  3 \x1b[1;31m- [ ] Todo C\x1b[0m\n",
+		out.String(),
+		"Colors are not applied by status")
+}
+
+func TestPrintDays(t *testing.T) {
+	date := time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC)
+	list := task.DayList{
+		{Date: date.AddDate(0, 0, -1), Todos: task.TodoList{{Description: "Todo A"}}},
+		{Date: date, Todos: task.TodoList{{Description: "Todo B", Complete: true}, {Description: "Todo C"}}},
+		{Date: date.AddDate(0, 0, 1), Todos: task.TodoList{{Description: "Todo D"}}},
+	}
+
+	days := printDays(list, date.Add(15*time.Hour))
+	var numbers []int
+	var labels, states []string
+	for _, day := range days {
+		for _, todo := range day.Todos {
+			numbers = append(numbers, todo.Number)
+			labels = append(labels, todo.Label)
+			states = append(states, todo.Status)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 3, 4}, numbers, "Todos are not numbered across days")
+	assert.Equal(t, []string{"Yesterday", "Today", "Today", "Tomorrow"}, labels, "Wrong labels relative to now")
+	assert.Equal(t, []string{"overdue", "done", "today", "open"}, states, "Wrong states relative to now")
+	assert.Equal(t, 1, days[1].Done, "Done todos of the day are not counted")
+	assert.Equal(t, 2, days[1].Total, "Todos of the day are not counted")
+}
+
+func TestRelativeDateLabel(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		date, current time.Time
+		expected      string
+	}{
+		{date(2017, 7, 17), date(2017, 7, 17), "Today"},
+		{date(2017, 7, 16), date(2017, 7, 17), "Yesterday"},
+		{date(2017, 7, 18), date(2017, 7, 17), "Tomorrow"},
+		{date(2017, 7, 15), date(2017, 7, 17), "Sat 15 Jul"},
+		// Sunday to Monday of the next week
+		{date(2017, 7, 24), date(2017, 7, 23), "Tomorrow"},
+		{date(2017, 7, 25), date(2017, 7, 23), "Tue 25 Jul"},
+		{date(2017, 7, 31), date(2017, 7, 30), "Tomorrow"},
+		{date(2017, 8, 1), date(2017, 7, 31), "Tomorrow"},
+		{date(2018, 1, 1), date(2017, 12, 31), "Tomorrow"},
+		{date(2017, 12, 31), date(2018, 1, 1), "Yesterday"},
+		{date(2018, 1, 2), date(2017, 12, 31), "Tue 2 Jan 2018"},
+		{date(2017, 12, 30), date(2018, 1, 1), "Sat 30 Dec 2017"},
+		{date(2017, 1, 1), date(2017, 12, 31), "Sun 1 Jan"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, relativeDateLabel(test.date, test.current),
+			"Wrong label for %s relative to %s", test.date.Format("2006-01-02"), test.current.Format("2006-01-02"))
+	}
+}
+
+func TestUseColors(t *testing.T) {
+	noColor, set := os.LookupEnv("NO_COLOR")
+	defer func() {
+		if set {
+			os.Setenv("NO_COLOR", noColor)
+		} else {
+			os.Unsetenv("NO_COLOR")
+		}
+	}()
+
+	tests := []struct {
+		flag     string
+		noColor  string
+		expected bool
+	}{
+		{"always", "", true},
+		{"always", "1", true},
+		{"never", "", false},
+		// The output of the tests is no terminal
+		{"auto", "", false},
+		{"", "", false},
+		{"auto", "1", false},
+		{"", "1", false},
+	}
+	for _, test := range tests {
+		os.Setenv("NO_COLOR", test.noColor)
+		colors, err := useColors(test.flag)
+		assert.Equal(t, nil, err, "Error for color mode %q is not nil", test.flag)
+		assert.Equal(t, test.expected, colors, "Wrong colors for mode %q with NO_COLOR=%q", test.flag, test.noColor)
+	}
+
+	_, err := useColors("sometimes")
+	assert.NotEqual(t, nil, err, "Unknown color mode is not reported")
+}
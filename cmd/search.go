@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/FChris/towg/parse"
+	"github.com/FChris/towg/task"
+	"github.com/urfave/cli"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func searchCommand() cli.Command {
+	return cli.Command{
+		Name: "search",
+		Usage: "lists the todos of all days which match the pattern with their date and number, which select them " +
+			"in other commands like 'switch -d 17.07.17 -n 2'",
+		ArgsUsage: "PATTERN",
+		Flags: []cli.Flag{
+			fileFlag(),
+			cli.BoolFlag{
+				Name:  "regex, r",
+				Usage: "the pattern is a regular expression instead of a text the todo contains",
+			},
+			cli.BoolFlag{
+				Name:  "fuzzy, z",
+				Usage: "the todo contains the characters of the pattern in the same order, with others in between",
+			},
+			cli.BoolFlag{
+				Name:  "ignore-case, i",
+				Usage: "ignore the case of the pattern and the todos",
+			},
+			cli.BoolFlag{
+				Name:  "open-only, o",
+				Usage: "only list open todos",
+			},
+			cli.StringFlag{
+				Name:  "color",
+				Usage: "'auto', 'always' or 'never'. Auto highlights the matches if the output is a terminal",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			fileName := c.String("file")
+			if fileName == "" {
+				fileName = fileNameDefault
+			}
+			pattern := strings.Join(c.Args(), " ")
+			if pattern == "" {
+				err := fmt.Errorf("No pattern given")
+				fmt.Println(err)
+				return err
+			}
+			match, err := searchMatcher(pattern, c.Bool("regex"), c.Bool("fuzzy"), c.Bool("ignore-case"))
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+			colors, err := useColors(c.String("color"))
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+
+			list, err := loadList(fileName)
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+			// Numbers are counted per day in the order of print, so they are valid with the date of the day
+			periodList, err := printPeriod(list, "-")
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+			found := 0
+			for _, day := range periodList {
+				for i, todo := range day.Todos {
+					if c.Bool("open-only") && todo.Complete {
+						continue
+					}
+					matches := match(todo.Text())
+					if matches == nil {
+						continue
+					}
+					text := todo.String()
+					if colors {
+						text = highlightTodo(todo, matches)
+					}
+					fmt.Printf("%s %3d %s\n", day.Date.Format(parse.Timeformat), i+1, text)
+					found++
+				}
+			}
+			if found == 0 {
+				fmt.Printf("No todo matches %q\n", pattern)
+			}
+			return nil
+		},
+	}
+}
+
+// searchMatcher returns a function which returns the byte ranges of the text matched by the pattern, or nil if the
+// text does not match
+func searchMatcher(pattern string, regex, fuzzy, ignoreCase bool) (func(text string) [][]int, error) {
+	if regex && fuzzy {
+		return nil, fmt.Errorf("Search either with --regex or --fuzzy")
+	}
+	if fuzzy {
+		return func(text string) [][]int {
+			return fuzzyMatches(text, pattern, ignoreCase)
+		}, nil
+	}
+
+	expr := pattern
+	if !regex {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		// The error names expr, which would show the (?i) added for --ignore-case
+		if e, ok := err.(*syntax.Error); ok {
+			return nil, fmt.Errorf("Invalid regular expression %q: %s", pattern, e.Code)
+		}
+		return nil, fmt.Errorf("Invalid regular expression %q: %s", pattern, err)
+	}
+	return func(text string) [][]int {
+		return re.FindAllStringIndex(text, -1)
+	}, nil
+}
+
+// fuzzyMatches returns the ranges of the characters of the pattern if the text contains them in the same order
+func fuzzyMatches(text, pattern string, ignoreCase bool) [][]int {
+	var matches [][]int
+	rest := pattern
+	for i, r := range text {
+		if rest == "" {
+			break
+		}
+		p, size := utf8.DecodeRuneInString(rest)
+		if r == p || ignoreCase && unicode.ToLower(r) == unicode.ToLower(p) {
+			matches = append(matches, []int{i, i + utf8.RuneLen(r)})
+			rest = rest[size:]
+		}
+	}
+	if rest != "" {
+		return nil
+	}
+	return matches
+}
+
+// highlightTodo returns the todo as printed with the ranges of its text highlighted. The ranges do not include the
+// checkbox, since it is not searched.
+func highlightTodo(todo task.Todo, matches [][]int) string {
+	text := todo.String()
+	offset := len(text) - len(todo.Text())
+	return text[:offset] + highlightMatches(text[offset:], matches)
+}
+
+// highlightMatches highlights the ranges of the text like print --watch highlights changes
+func highlightMatches(text string, matches [][]int) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m[0] == m[1] {
+			continue
+		}
+		b.WriteString(text[last:m[0]])
+		b.WriteString(ansiHighlight + text[m[0]:m[1]] + ansiReset)
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"github.com/FChris/towg/task"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSearchMatcher(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		regex      bool
+		fuzzy      bool
+		ignoreCase bool
+		text       string
+		expected   [][]int
+	}{
+		{"mom", false, false, false, "Call mom, mom", [][]int{{5, 8}, {10, 13}}},
+		{"Mom", false, false, false, "Call mom", nil},
+		{"Mom", false, false, true, "Call mom", [][]int{{5, 8}}},
+		{"(A)", false, false, false, "(A) Call", [][]int{{0, 3}}},
+		{"^call", true, false, false, "Call mom", nil},
+		{"^call", true, false, true, "Call mom", [][]int{{0, 4}}},
+		{"m.m$", true, false, false, "Call mom", [][]int{{5, 8}}},
+		{"clmm", false, true, false, "call mom", [][]int{{0, 1}, {2, 3}, {5, 6}, {7, 8}}},
+		{"CLMM", false, true, false, "call mom", nil},
+		{"CLMM", false, true, true, "call mom", [][]int{{0, 1}, {2, 3}, {5, 6}, {7, 8}}},
+		{"mc", false, true, false, "call mom", nil},
+		{"éü", false, true, false, "Café über", [][]int{{3, 5}, {6, 8}}},
+		{"ÉÜ", false, true, true, "Café über", [][]int{{3, 5}, {6, 8}}},
+		{"é", false, false, false, "Café über", [][]int{{3, 5}}},
+	}
+	for _, test := range tests {
+		match, err := searchMatcher(test.pattern, test.regex, test.fuzzy, test.ignoreCase)
+		assert.Equal(t, nil, err, "Error for pattern %q is not nil", test.pattern)
+		assert.Equal(t, test.expected, match(test.text), "Wrong matches of %q in %q with regex %v, fuzzy %v, "+
+			"ignore case %v", test.pattern, test.text, test.regex, test.fuzzy, test.ignoreCase)
+	}
+
+	_, err := searchMatcher("mom", true, true, false)
+	assert.NotEqual(t, nil, err, "Searching with --regex and --fuzzy is not rejected")
+
+	_, err = searchMatcher("call (", true, false, true)
+	assert.EqualError(t, err, `Invalid regular expression "call (": missing closing )`,
+		"Error does not name the pattern as given")
+	_, err = searchMatcher("call (", false, false, true)
+	assert.Equal(t, nil, err, "Text which is no regular expression is not quoted")
+}
+
+func TestFuzzyMatches(t *testing.T) {
+	assert.Equal(t, [][]int{{0, 2}, {4, 7}}, fuzzyMatches("äbc€", "ä€", false), "Multibyte runes are not matched")
+	assert.Equal(t, [][]int{{0, 2}}, fuzzyMatches("Äb", "ä", true), "Case of multibyte runes is not ignored")
+	assert.Nil(t, fuzzyMatches("äb", "bä", false), "Runes in a different order match")
+	assert.Nil(t, fuzzyMatches("ä", "ää", false), "Rune matches twice")
+}
+
+func TestHighlightTodo(t *testing.T) {
+	todo := task.Todo{Description: "Call mom", Complete: true, Priority: "A"}
+	match, err := searchMatcher("call", false, false, true)
+	assert.Equal(t, nil, err, "Error for the pattern is not nil")
+	assert.Equal(t, "- [x] (A) \x1b[7mCall\x1b[0m mom", highlightTodo(todo, match(todo.Text())),
+		"Highlight does not skip the checkbox")
+
+	match, err = searchMatcher("(A)", false, false, false)
+	assert.Equal(t, nil, err, "Error for the pattern is not nil")
+	assert.Equal(t, "- [x] \x1b[7m(A)\x1b[0m Call mom", highlightTodo(todo, match(todo.Text())),
+		"Highlight does not skip the checkbox")
+
+	todo = task.Todo{Description: "Café über"}
+	match, err = searchMatcher("éü", false, true, false)
+	assert.Equal(t, nil, err, "Error for the pattern is not nil")
+	assert.Equal(t, "- [ ] Caf\x1b[7mé\x1b[0m \x1b[7mü\x1b[0mber", highlightTodo(todo, match(todo.Text())),
+		"Multibyte runes are not highlighted")
+}
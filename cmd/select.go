@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/FChris/towg/parse"
+	"github.com/FChris/towg/task"
+	"github.com/urfave/cli"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// selectedTodo is a todo as numbered by print. It is identified by its day and text instead of its index, so
+// that a selection stays valid while the selected todos are changed one after another.
+type selectedTodo struct {
+	number int
+	date   time.Time
+	todo   task.Todo
+
+	// missing is true for todos of the list printed last which no longer exist
+	missing bool
+}
+
+// selectionFlags are the flags of the commands which change one or more todos. verb describes what happens to the
+// selected todos.
+func selectionFlags(verb string) []cli.Flag {
+	return append(todoFlags(verb),
+		cli.BoolFlag{
+			Name:  "all",
+			Usage: "select all todos of the date or, if no date is given, of the list printed last, or all matching todos",
+		},
+		cli.BoolFlag{
+			Name:  "open",
+			Usage: "select the open todos like --all, or only the open ones of the given numbers",
+		},
+		cli.BoolFlag{
+			Name:  "done",
+			Usage: "select the done todos like --all, or only the done ones of the given numbers",
+		},
+	)
+}
+
+// todoFlags select todos by number or text only
+func todoFlags(verb string) []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name: "number, n",
+			Usage: "numbers of the todos which " + verb + ", as shown by print, like '3' or '1,3,5-8'." +
+				"\n\tIf no date is given the numbers refer to the list printed last",
+		},
+		cli.StringFlag{
+			Name: "match, m",
+			Usage: "select the todo whose description matches the text instead of giving its number. " +
+				"\n\tA text like '/^call .*mom$/' is a regular expression. Other texts match exactly, as part of " +
+				"\n\tthe description or with other characters in between, ignoring case",
+		},
+	}
+}
+
+// selectTodos returns the todos chosen by the selection flags in the order of their numbers. All of them exist in
+// list.
+//
+// If a text matches several todos the user is asked which one is meant. If towg does not run in a terminal it fails
+// and lists the todos instead.
+func selectTodos(c *cli.Context, list task.DayList, fileName string) ([]selectedTodo, error) {
+	numbered, fromView, err := numberedTodos(c, list, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []selectedTodo
+	switch {
+	case c.IsSet("number") && c.IsSet("match"):
+		return nil, fmt.Errorf("Select todos either by number or by text")
+	case c.IsSet("match"):
+		if selected, err = matchTodos(c.String("match"), numbered); err != nil {
+			return nil, err
+		}
+	case c.IsSet("number"):
+		numbers, err := parseNumbers(c.String("number"), len(numbered))
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range numbers {
+			s, err := numberedTodo(numbered, fromView, n)
+			if err != nil {
+				return nil, err
+			}
+			selected = append(selected, s)
+		}
+	case c.Bool("all") || c.Bool("open") || c.Bool("done"):
+		// Todos of the list printed last which no longer exist are not selected by --all
+		for _, s := range numbered {
+			if !s.missing {
+				selected = append(selected, s)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("No todo selected. Give the numbers of the todos or use --all, --open or --done")
+	}
+
+	// Either status flag alone restricts the selection, both together select every status
+	if c.Bool("open") != c.Bool("done") {
+		var filtered []selectedTodo
+		for _, s := range selected {
+			if s.missing || s.todo.Complete == c.Bool("done") {
+				filtered = append(filtered, s)
+			}
+		}
+		selected = filtered
+	}
+
+	for _, s := range selected {
+		if s.missing {
+			return nil, fmt.Errorf("Todo %d of the list printed last no longer exists. Print the list again", s.number)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("No todo matches the selection")
+	}
+	if c.IsSet("match") && len(selected) > 1 && !c.Bool("all") {
+		return chooseTodos(c.String("match"), selected)
+	}
+	return selected, nil
+}
+
+// matchTodos returns the todos whose description matches the text. A text between slashes is a regular
+// expression. Otherwise descriptions equal to the text are preferred over descriptions containing it, which are
+// preferred over descriptions containing its characters in the same order. Case is ignored.
+func matchTodos(text string, numbered []selectedTodo) ([]selectedTodo, error) {
+	var tiers [3][]selectedTodo
+	if len(text) > 1 && strings.HasPrefix(text, "/") && strings.HasSuffix(text, "/") {
+		re, err := regexp.Compile(text[1 : len(text)-1])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid regular expression %s: %s", text, err)
+		}
+		for _, s := range numbered {
+			if !s.missing && re.MatchString(s.todo.Description) {
+				tiers[0] = append(tiers[0], s)
+			}
+		}
+	} else {
+		lower := strings.ToLower(text)
+		for _, s := range numbered {
+			desc := strings.ToLower(s.todo.Description)
+			switch {
+			case s.missing:
+			case desc == lower:
+				tiers[0] = append(tiers[0], s)
+			case strings.Contains(desc, lower):
+				tiers[1] = append(tiers[1], s)
+			case containsInOrder(desc, lower):
+				tiers[2] = append(tiers[2], s)
+			}
+		}
+	}
+
+	for _, tier := range tiers {
+		if len(tier) > 0 {
+			return tier, nil
+		}
+	}
+	return nil, fmt.Errorf("No todo matches %q", text)
+}
+
+// containsInOrder returns true if all characters of sub appear in s in the same order
+func containsInOrder(s, sub string) bool {
+	for _, r := range sub {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+utf8.RuneLen(r):]
+	}
+	return true
+}
+
+// chooseTodos asks which of the todos matching the text is meant
+func chooseTodos(text string, matches []selectedTodo) ([]selectedTodo, error) {
+	var candidates strings.Builder
+	for i, s := range matches {
+		fmt.Fprintf(&candidates, "\n  %d) %s %s (number %d)", i+1, s.date.Format(parse.Timeformat), s.todo, s.number)
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil, fmt.Errorf("%q matches several todos. Use a more specific text, --number or --all:%s", text,
+			candidates.String())
+	}
+
+	fmt.Printf("%q matches several todos:%s\n", text, candidates.String())
+	in := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("Which ones do you mean? [1-%d, like '1' or '1,3', empty to cancel]: ", len(matches))
+		answer, err := in.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			return nil, fmt.Errorf("No todo selected")
+		}
+		var chosen []selectedTodo
+		numbers, parseErr := parseNumbers(answer, len(matches))
+		for _, n := range numbers {
+			if n <= len(matches) {
+				chosen = append(chosen, matches[n-1])
+			}
+		}
+		if parseErr == nil && len(chosen) == len(numbers) {
+			return chosen, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("No todo selected")
+		}
+	}
+}
+
+// selectTodo returns the day and the index within the day of the n-th todo
+func selectTodo(c *cli.Context, list task.DayList, fileName string, n int) (date time.Time, ind int, err error) {
+	numbered, fromView, err := numberedTodos(c, list, fileName)
+	if err != nil {
+		return date, 0, err
+	}
+	s, err := numberedTodo(numbered, fromView, n)
+	if err != nil {
+		return date, 0, err
+	}
+	if s.missing {
+		return date, 0, fmt.Errorf("Todo %d of the list printed last no longer exists. Print the list again", n)
+	}
+	return s.date, indexOfTodo(list, s.date, s.todo.Text()), nil
+}
+
+// numberedTodos returns the todos as numbered by print. If no date is given and the file was printed before these
+// are the todos of the last printed view, otherwise those of the period of the date flag.
+func numberedTodos(c *cli.Context, list task.DayList, fileName string) (numbered []selectedTodo, fromView bool,
+	err error) {
+	return numberedTodosOfPeriod(list, fileName, c.String("date"))
+}
+
+// numberedTodosOfPeriod returns the todos as numbered by print for the period, or for the last printed view if the
+// period is empty like numberedTodos
+func numberedTodosOfPeriod(list task.DayList, fileName, period string) (numbered []selectedTodo, fromView bool,
+	err error) {
+	if period == "" {
+		v, err := loadView(fileName)
+		if err != nil {
+			return nil, false, err
+		}
+		if v != nil {
+			for i, entry := range v.Todos {
+				date, err := time.Parse(task.DateFormat, entry.Date)
+				if err != nil {
+					return nil, false, fmt.Errorf("Error while reading last view: %s", err)
+				}
+				s := selectedTodo{number: i + 1, date: date, todo: task.ParseTodo(entry.Text, false)}
+				if ind := indexOfTodo(list, date, entry.Text); ind >= 0 {
+					s.todo = list.DayByDate(date).Todos[ind]
+				} else {
+					s.missing = true
+				}
+				numbered = append(numbered, s)
+			}
+			return numbered, true, nil
+		}
+	}
+
+	if period == "" {
+		period = today
+	}
+	periodList, err := printPeriod(list, period)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, day := range periodList {
+		for _, todo := range day.Todos {
+			numbered = append(numbered, selectedTodo{number: len(numbered) + 1, date: day.Date, todo: todo})
+		}
+	}
+	return numbered, false, nil
+}
+
+// numberedTodo returns the n-th of the numbered todos
+func numberedTodo(numbered []selectedTodo, fromView bool, n int) (selectedTodo, error) {
+	if n < 1 || n > len(numbered) {
+		if fromView {
+			return selectedTodo{}, fmt.Errorf("The list printed last has no todo %d", n)
+		}
+		return selectedTodo{}, fmt.Errorf("There is no todo %d", n)
+	}
+	return numbered[n-1], nil
+}
+
+// parseNumbers parses a comma separated list of numbers and ranges like "1,3,5-8". Numbers given twice are only
+// returned once. Ranges end at the first number larger than max, since there are no todos with larger numbers.
+func parseNumbers(s string, max int) ([]int, error) {
+	var numbers []int
+	seen := map[int]bool{}
+	for _, part := range strings.Split(s, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		last := first
+		if err == nil && len(bounds) == 2 {
+			last, err = strconv.Atoi(strings.TrimSpace(bounds[1]))
+		}
+		if err != nil || first < 1 || last < first {
+			return nil, fmt.Errorf("Invalid number %q, expected numbers like '3' or '1,3,5-8'", strings.TrimSpace(part))
+		}
+
+		for n := first; n <= last; n++ {
+			if !seen[n] {
+				seen[n] = true
+				numbers = append(numbers, n)
+			}
+			if n > max {
+				break
+			}
+		}
+	}
+	return numbers, nil
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"flag"
+	"github.com/FChris/towg/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseNumbers(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected []int
+	}{
+		{"3", []int{3}},
+		{"1,3,5-8", []int{1, 3, 5, 6, 7, 8}},
+		{" 2 , 2,1-3", []int{2, 1, 3}},
+		{"9-100", []int{9, 10, 11}},
+	}
+	for _, test := range tests {
+		numbers, err := parseNumbers(test.s, 10)
+		assert.Equal(t, nil, err, "Error for parsing %q is not nil", test.s)
+		assert.Equal(t, test.expected, numbers, "Numbers of %q are wrong", test.s)
+	}
+
+	for _, s := range []string{"", "0", "a", "3-1", "1,,2", "-2"} {
+		_, err := parseNumbers(s, 10)
+		assert.NotEqual(t, nil, err, "Invalid numbers %q are not reported", s)
+	}
+}
+
+func TestMatchTodos(t *testing.T) {
+	numbered := []selectedTodo{
+		{number: 1, todo: task.Todo{Description: "Call Mom about the meatballs"}},
+		{number: 2, todo: task.Todo{Description: "call mom"}},
+		{number: 3, todo: task.Todo{Description: "Cancel meeting"}},
+		{number: 4, todo: task.Todo{Description: "Call Mom"}, missing: true},
+	}
+	numbers := func(selected []selectedTodo) []int {
+		var n []int
+		for _, s := range selected {
+			n = append(n, s.number)
+		}
+		return n
+	}
+
+	tests := []struct {
+		text     string
+		expected []int
+	}{
+		{"Call Mom", []int{2}},
+		{"mom", []int{1, 2}},
+		{"cmt", []int{1, 3}},
+		{"/^Ca.*g$/", []int{3}},
+		{"/(?i)^call/", []int{1, 2}},
+	}
+	for _, test := range tests {
+		selected, err := matchTodos(test.text, numbered)
+		assert.Equal(t, nil, err, "Error for matching %q is not nil", test.text)
+		assert.Equal(t, test.expected, numbers(selected), "Todos matching %q are wrong", test.text)
+	}
+
+	_, err := matchTodos("dentist", numbered)
+	assert.NotEqual(t, nil, err, "Text matching no todo is not reported")
+	_, err = matchTodos("/(/", numbered)
+	assert.NotEqual(t, nil, err, "Invalid regular expression is not reported")
+}
+
+// testContext returns the context of a command with the selection flags and the given arguments
+func testContext(t *testing.T, args ...string) *cli.Context {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for _, f := range append(selectionFlags("are tested"), dateFlag()) {
+		f.Apply(set)
+	}
+	assert.Equal(t, nil, set.Parse(args), "Error for parsing the arguments is not nil")
+	return cli.NewContext(nil, set, nil)
+}
+
+func TestSelectTodos(t *testing.T) {
+	dir, err := ioutil.TempDir("", "towg")
+	assert.Equal(t, nil, err, "Error for creating the directory is not nil")
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "test.todo")
+
+	date := time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC)
+	list := task.DayList{
+		{Date: date, Todos: task.TodoList{{Description: "Todo 1", Complete: true}, {Description: "Todo 2"}}},
+		{Date: date.AddDate(0, 0, -1), Todos: task.TodoList{{Description: "Todo 3"}, {Description: "Todo 4"}}},
+	}
+	assert.Equal(t, nil, newView("-", list).save(fileName), "Error for saving the view is not nil")
+	// Todo 2 of the printed list is deleted afterwards
+	assert.Equal(t, nil, list.DeleteTodo(date, 1), "Error for deleting the todo is not nil")
+
+	descriptions := func(args ...string) ([]string, error) {
+		selected, err := selectTodos(testContext(t, args...), list, fileName)
+		var desc []string
+		for _, s := range selected {
+			desc = append(desc, s.todo.Description)
+		}
+		return desc, err
+	}
+
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"--number", "3,1"}, []string{"Todo 3", "Todo 1"}},
+		{[]string{"--all"}, []string{"Todo 1", "Todo 3", "Todo 4"}},
+		{[]string{"--open"}, []string{"Todo 3", "Todo 4"}},
+		{[]string{"--done", "--number", "1,3-4"}, []string{"Todo 1"}},
+		{[]string{"--match", "todo", "--all"}, []string{"Todo 1", "Todo 3", "Todo 4"}},
+		{[]string{"--match", "todo 4"}, []string{"Todo 4"}},
+		{[]string{"--date", "16.07.17", "--number", "2"}, []string{"Todo 4"}},
+	}
+	for _, test := range tests {
+		selected, err := descriptions(test.args...)
+		assert.Equal(t, nil, err, "Error for selecting %v is not nil", test.args)
+		assert.Equal(t, test.expected, selected, "Todos selected by %v are wrong", test.args)
+	}
+
+	invalid := [][]string{{"--number", "2"}, {"--number", "5"}, {"--number", "1", "--match", "Todo"}, {},
+		{"--done", "--number", "3"}}
+	for _, args := range invalid {
+		_, err := descriptions(args...)
+		assert.NotEqual(t, nil, err, "Invalid selection %v is not reported", args)
+	}
+}
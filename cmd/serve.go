@@ -0,0 +1,410 @@
+package cmd
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"github.com/FChris/towg/convert"
+	"github.com/FChris/towg/parse"
+	"github.com/FChris/towg/task"
+	"github.com/urfave/cli"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+func serveCommand() cli.Command {
+	return cli.Command{
+		Name:  "serve",
+		Usage: "serves the todo file as HTTP/JSON API for widgets and editor plugins",
+		Flags: []cli.Flag{
+			fileFlag(),
+			cli.StringFlag{
+				Name:  "addr",
+				Value: "127.0.0.1:8080",
+				Usage: "address to listen on",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			fileName := c.String("file")
+			if fileName == "" {
+				fileName = fileNameDefault
+			}
+			s := &server{fileName: fileName, addr: c.String("addr")}
+			if err := s.reload(); err != nil {
+				fmt.Println(err)
+				return err
+			}
+
+			fmt.Printf("Serving %s on http://%s/api/\n", fileName, c.String("addr"))
+			err := http.ListenAndServe(c.String("addr"), s.handler())
+			fmt.Println(err)
+			return err
+		},
+	}
+}
+
+// server serves the todo list of a file. Requests are handled one at a time, so writes are serialised, and the
+// file is read again whenever it was changed by another program.
+type server struct {
+	mu       sync.Mutex
+	fileName string
+	list     task.DayList
+
+	// addr is the address the server listens on. Requests may name its host besides the loopback addresses.
+	addr string
+
+	// modTime and size of the file when it was read or written last. A zero modTime forces a reload.
+	modTime time.Time
+	size    int64
+}
+
+// apiTodo is a todo in the responses of the API. The ID stays the same as long as day and description do.
+type apiTodo struct {
+	ID   string `json:"id"`
+	Date string `json:"date"`
+	convert.TodoRecord
+}
+
+// apiError is the body of all responses with an error status
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// statusError is an error with the HTTP status it is reported with
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e statusError) Error() string {
+	return e.err.Error()
+}
+
+func errorStatus(status int, format string, args ...interface{}) error {
+	return statusError{status: status, err: fmt.Errorf(format, args...)}
+}
+
+// handler returns the routes of the API:
+//
+//	GET    /api/days?date=week          the days of a period in the json export schema, all days by default
+//	GET    /api/todos?date=-&q=milk     the todos of a period, today by default, optionally containing a text
+//	POST   /api/todos                   adds {"text": "buy milk", "date": "today"}, today by default
+//	GET    /api/todos/{id}              a single todo
+//	PATCH  /api/todos/{id}              changes {"text": ..., "complete": true, "date": "2017-07-18"}, all optional
+//	DELETE /api/todos/{id}              deletes a todo
+//
+// Dates are given as yyyy-mm-dd or like on the command line. Changes must be sent as application/json, which web
+// pages of other sites can only send after asking the server, and the Host header must name a loopback address or
+// the host the server listens on, so that pages can not reach the API by a name of their own which resolves to it.
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/days", s.handle(s.days))
+	mux.HandleFunc("/api/todos", s.handle(s.todos))
+	mux.HandleFunc("/api/todos/", s.handle(s.todo))
+	return mux
+}
+
+// handle wraps an API function. It locks the server, reloads the file if necessary and writes the result or the
+// error as JSON.
+func (s *server) handle(fn func(r *http.Request) (int, interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		status, result, err := s.call(fn, r)
+		s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			status = http.StatusInternalServerError
+			if se, ok := err.(statusError); ok {
+				status = se.status
+			}
+			result = apiError{Error: err.Error()}
+		}
+		w.WriteHeader(status)
+		if status != http.StatusNoContent {
+			json.NewEncoder(w).Encode(result)
+		}
+	}
+}
+
+func (s *server) call(fn func(r *http.Request) (int, interface{}, error), r *http.Request) (int, interface{}, error) {
+	if err := s.checkRequest(r); err != nil {
+		return 0, nil, err
+	}
+	if r.Method != http.MethodGet {
+		// Changes hold the lock of the file from reading it until saving it, like the commands do
+		unlock, err := lockFile(s.fileName)
+		if err != nil {
+			return 0, nil, err
+		}
+		defer unlock()
+	}
+	if err := s.reloadIfChanged(); err != nil {
+		return 0, nil, err
+	}
+	status, result, err := fn(r)
+	if err != nil && r.Method != http.MethodGet {
+		// Failed changes may have modified the list in memory, so it is read again
+		s.modTime = time.Time{}
+	}
+	return status, result, err
+}
+
+// checkRequest returns an error if the request does not name an allowed host or is a change not sent as JSON
+func (s *server) checkRequest(r *http.Request) error {
+	if !s.allowedHost(r.Host) {
+		return errorStatus(http.StatusForbidden, "Host %q is not allowed", r.Host)
+	}
+	if r.Method != http.MethodGet {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			return errorStatus(http.StatusUnsupportedMediaType, "Changes must be sent as application/json")
+		}
+	}
+	return nil
+}
+
+// allowedHost returns true if the host of a request is localhost, a loopback address or the host of addr
+func (s *server) allowedHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if host == "" {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	addrHost, _, err := net.SplitHostPort(s.addr)
+	return err == nil && strings.EqualFold(host, addrHost)
+}
+
+func (s *server) reloadIfChanged() error {
+	info, err := os.Stat(s.fileName)
+	if os.IsNotExist(err) {
+		s.list, s.modTime, s.size = nil, time.Time{}, 0
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if s.modTime.IsZero() || !info.ModTime().Equal(s.modTime) || info.Size() != s.size {
+		return s.reload()
+	}
+	return nil
+}
+
+func (s *server) reload() error {
+	list, err := loadListOrEmpty(s.fileName)
+	if err != nil {
+		return err
+	}
+	s.list = list
+	return s.stat()
+}
+
+// stat remembers the modification time and size of the file
+func (s *server) stat() error {
+	info, err := os.Stat(s.fileName)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	s.modTime, s.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// save writes the changed list through the same code path as the commands of the command line and commits it with
+// the message in git mode. The lock of the file is held by call.
+func (s *server) save(list task.DayList, message string) error {
+	list, err := save(list, s.fileName)
+	if !saved(err) {
+		return err
+	}
+	s.list = list
+	if statErr := s.stat(); statErr != nil {
+		return statErr
+	}
+	if err != nil {
+		// The file was saved, but some todos are marked as conflicts
+		return statusError{status: http.StatusConflict, err: err}
+	}
+	return commitChange(s.fileName, message)
+}
+
+func (s *server) days(r *http.Request) (int, interface{}, error) {
+	if r.Method != http.MethodGet {
+		return 0, nil, errorStatus(http.StatusMethodNotAllowed, "Method %s is not allowed", r.Method)
+	}
+	period := r.URL.Query().Get("date")
+	if period == "" {
+		period = "-"
+	}
+	list, err := dayListByPeriod(s.list, period)
+	if err != nil {
+		return 0, nil, statusError{status: http.StatusBadRequest, err: err}
+	}
+	return http.StatusOK, convert.ToRecords(list), nil
+}
+
+func (s *server) todos(r *http.Request) (int, interface{}, error) {
+	switch r.Method {
+	case http.MethodGet:
+		period := r.URL.Query().Get("date")
+		if period == "" {
+			period = today
+		}
+		list, err := dayListByPeriod(s.list, period)
+		if err != nil {
+			return 0, nil, statusError{status: http.StatusBadRequest, err: err}
+		}
+
+		query := strings.ToLower(r.URL.Query().Get("q"))
+		todos := []apiTodo{}
+		for _, day := range list {
+			for _, todo := range day.Todos {
+				if strings.Contains(strings.ToLower(todo.Text()), query) {
+					todos = append(todos, newAPITodo(day.Date, todo))
+				}
+			}
+		}
+		return http.StatusOK, todos, nil
+
+	case http.MethodPost:
+		var req struct {
+			Text string `json:"text"`
+			Date string `json:"date"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return 0, nil, errorStatus(http.StatusBadRequest, "Invalid request: %s", err)
+		}
+		date, err := apiDate(req.Date)
+		if err != nil {
+			return 0, nil, err
+		}
+		todo := task.ParseTodo(strings.TrimSpace(req.Text), false)
+		if todo.Description == "" {
+			return 0, nil, errorStatus(http.StatusBadRequest, "The text of a todo must not be empty")
+		}
+
+		s.list.InsertTodoInOrder(date, todo, fileOrder)
+		if err = s.save(s.list, changeMessage("add", todo, date, "")); err != nil {
+			return 0, nil, err
+		}
+		return http.StatusCreated, newAPITodo(date, todo), nil
+	}
+	return 0, nil, errorStatus(http.StatusMethodNotAllowed, "Method %s is not allowed", r.Method)
+}
+
+func (s *server) todo(r *http.Request) (int, interface{}, error) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/todos/")
+	date, ind, ok := findAPITodo(s.list, id)
+	if !ok {
+		return 0, nil, errorStatus(http.StatusNotFound, "There is no todo %s", id)
+	}
+	todo := s.list.DayByDate(date).Todos[ind]
+
+	switch r.Method {
+	case http.MethodGet:
+		return http.StatusOK, newAPITodo(date, todo), nil
+
+	case http.MethodDelete:
+		if err := s.list.DeleteTodo(date, ind); err != nil {
+			return 0, nil, err
+		}
+		return http.StatusNoContent, nil, s.save(s.list, changeMessage("delete", todo, date, ""))
+
+	case http.MethodPatch:
+		var req struct {
+			Text     *string `json:"text"`
+			Complete *bool   `json:"complete"`
+			Date     *string `json:"date"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return 0, nil, errorStatus(http.StatusBadRequest, "Invalid request: %s", err)
+		}
+
+		list := s.list
+		var err error
+		var changes []string
+		if req.Text != nil {
+			if list, err = editTodo(list, date, ind, *req.Text); err != nil {
+				return 0, nil, statusError{status: http.StatusBadRequest, err: err}
+			}
+			edited := task.ParseTodo(strings.TrimSpace(*req.Text), todo.Complete)
+			changes = append(changes, changeMessage("edit", todo, date, edited.Description))
+			todo = edited
+			ind = indexOfTodo(list, date, todo.Text())
+		}
+		if req.Complete != nil && *req.Complete != todo.Complete {
+			switchTodoStatus(list, date, ind)
+			changes = append(changes, changeMessage("switch", todo, date, statusName(*req.Complete)))
+			todo.Complete = *req.Complete
+			ind = indexOfTodo(list, date, todo.Text())
+		}
+		if req.Date != nil {
+			newDate, err := apiDate(*req.Date)
+			if err != nil {
+				return 0, nil, err
+			}
+			if list, err = changeDateOfTodo(list, date, ind, newDate); err != nil {
+				return 0, nil, statusError{status: http.StatusConflict, err: err}
+			}
+			changes = append(changes, changeMessage("redate", todo, date, newDate.Format(parse.Timeformat)))
+			date = newDate
+			ind = indexOfTodo(list, date, todo.Text())
+		}
+
+		if err = s.save(list, joinMessages("serve", changes)); err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, newAPITodo(date, list.DayByDate(date).Todos[ind]), nil
+	}
+	return 0, nil, errorStatus(http.StatusMethodNotAllowed, "Method %s is not allowed", r.Method)
+}
+
+func newAPITodo(date time.Time, todo task.Todo) apiTodo {
+	return apiTodo{ID: apiTodoID(date, todo), Date: date.Format(task.DateFormat), TodoRecord: convert.ToRecord(todo)}
+}
+
+// apiTodoID identifies a todo by its day and text
+func apiTodoID(date time.Time, todo task.Todo) string {
+	sum := sha1.Sum([]byte(date.Format(task.DateFormat) + "\n" + todo.Text()))
+	return fmt.Sprintf("%x", sum[:6])
+}
+
+// findAPITodo returns the day and the index within the day of the todo with the given id
+func findAPITodo(list task.DayList, id string) (date time.Time, ind int, ok bool) {
+	for _, day := range list {
+		for i, todo := range day.Todos {
+			if apiTodoID(day.Date, todo) == id {
+				return day.Date, i, true
+			}
+		}
+	}
+	return date, 0, false
+}
+
+// apiDate parses a date given as yyyy-mm-dd or like on the command line. An empty date is today.
+func apiDate(s string) (time.Time, error) {
+	if s == "" {
+		s = today
+	}
+	if date, err := time.Parse(task.DateFormat, s); err == nil {
+		return date, nil
+	}
+	date, err := dateByDescription(s)
+	if err != nil {
+		return date, errorStatus(http.StatusBadRequest, "Invalid date %q", s)
+	}
+	return date, nil
+}
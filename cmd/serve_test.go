@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServer_CheckRequest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "towg")
+	assert.Equal(t, nil, err, "Error for creating the directory is not nil")
+	defer os.RemoveAll(dir)
+
+	s := &server{fileName: filepath.Join(dir, "test.todo"), addr: "todo.lan:8080"}
+	assert.Equal(t, nil, s.reload(), "Error for loading the missing file is not nil")
+	handler := s.handler()
+
+	request := func(method, host, contentType string) int {
+		r := httptest.NewRequest(method, "http://"+host+"/api/todos", strings.NewReader(`{"text": "Buy milk"}`))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	tests := []struct {
+		method      string
+		host        string
+		contentType string
+		status      int
+	}{
+		{http.MethodGet, "127.0.0.1:8080", "", http.StatusOK},
+		{http.MethodGet, "[::1]:8080", "", http.StatusOK},
+		{http.MethodGet, "todo.lan:8080", "", http.StatusOK},
+		{http.MethodGet, "attacker.example:8080", "", http.StatusForbidden},
+		{http.MethodPost, "attacker.example:8080", "application/json", http.StatusForbidden},
+		{http.MethodPost, "localhost:8080", "", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "localhost:8080", "text/plain", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "localhost:8080", "application/json; charset=utf-8", http.StatusCreated},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.status, request(test.method, test.host, test.contentType),
+			"Wrong status for %s with host %s and content type %q", test.method, test.host, test.contentType)
+	}
+
+	list, err := loadList(s.fileName)
+	assert.Equal(t, nil, err, "Error for loading the file is not nil")
+	assert.Equal(t, 1, len(list), "Rejected requests changed the file")
+	assert.Equal(t, 1, len(list[0].Todos), "Rejected requests changed the file")
+}
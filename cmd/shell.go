@@ -0,0 +1,332 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/FChris/towg/task"
+	"github.com/urfave/cli"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// session keeps the list of a todo file in memory between the commands typed into the shell
+type session struct {
+	fileName string
+	list     task.DayList
+	changed  bool
+
+	// messages describe the changes since the last save for the commit of git mode
+	messages []string
+}
+
+// currentSession is the session of the running shell. It is nil when towg runs a single command.
+var currentSession *session
+
+// loadList returns the list of the todo file. Within a shell the list of the session is returned instead of
+// parsing the file again.
+func loadList(fileName string) (task.DayList, error) {
+	if s := currentSession; s != nil && s.fileName == fileName {
+		return s.list, nil
+	}
+	return parseFromFile(fileName)
+}
+
+// loadListOrEmpty returns the list of the todo file like loadList, or an empty list if the file does not exist yet
+func loadListOrEmpty(fileName string) (task.DayList, error) {
+	if s := currentSession; s == nil || s.fileName != fileName {
+		if _, err := os.Stat(fileName); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+	return loadList(fileName)
+}
+
+// saveList writes the list to the todo file and commits it with the message in git mode. Within a shell the list is
+// only kept in the session until it is saved by the save or exit command.
+func saveList(list task.DayList, fileName string, message string) error {
+	if s := currentSession; s != nil && s.fileName == fileName {
+		s.list = list
+		s.changed = true
+		s.messages = append(s.messages, message)
+		return nil
+	}
+	// A file with conflict markers is not committed
+	if _, err := save(list, fileName); err != nil {
+		return err
+	}
+	return commitChange(fileName, message)
+}
+
+// shellCommands are the commands of the shell in addition to the subcommands of towg
+var shellCommands = []string{"save", "exit", "abort", "help"}
+
+// shellPeriods and shellDates are suggested by tab completion after commands which take a period or a date
+var (
+	shellPeriods = []string{yesterday, today, tomorrow, week, "-"}
+	shellDates   = []string{yesterday, today, tomorrow}
+)
+
+func shellCommand() cli.Command {
+	return cli.Command{
+		Name:  "shell",
+		Usage: "loads the todo file once and reads commands like 'add', 'switch 3' or 'print week' from a prompt",
+		Flags: []cli.Flag{
+			fileFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			if currentSession != nil {
+				err := fmt.Errorf("The shell is already running")
+				fmt.Println(err)
+				return err
+			}
+			fileName := c.String("file")
+			if fileName == "" {
+				fileName = fileNameDefault
+			}
+			list, err := loadListOrEmpty(fileName)
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+
+			currentSession = &session{fileName: fileName, list: list}
+			defer func() { currentSession = nil }()
+			return runShell(c.App, currentSession)
+		},
+	}
+}
+
+// runShell reads commands until exit or abort and runs them with the given app. If stdin is a terminal the prompt
+// offers a history and tab completion, otherwise the commands are read line by line, which allows to pipe a batch
+// of commands into towg.
+func runShell(app *cli.App, s *session) error {
+	fd := int(os.Stdin.Fd())
+	var readLine func() (string, error)
+	if term.IsTerminal(fd) {
+		t := term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, os.Stdout}, "")
+		t.AutoCompleteCallback = completeShellLine
+		readLine = func() (string, error) {
+			prompt := "towg> "
+			if s.changed {
+				prompt = "towg*> "
+			}
+			t.SetPrompt(prompt)
+
+			// The terminal is only raw while typing, so that commands print as usual
+			state, err := term.MakeRaw(fd)
+			if err != nil {
+				return "", err
+			}
+			defer term.Restore(fd, state)
+			return t.ReadLine()
+		}
+	} else {
+		scanner := bufio.NewScanner(os.Stdin)
+		readLine = func() (string, error) {
+			if !scanner.Scan() {
+				if scanner.Err() != nil {
+					return "", scanner.Err()
+				}
+				return "", io.EOF
+			}
+			return scanner.Text(), nil
+		}
+	}
+
+	for {
+		line, err := readLine()
+		if err == io.EOF {
+			// Ctrl-D or the end of the input exit like the exit command
+			return s.save()
+		} else if err != nil {
+			return err
+		}
+
+		args, err := splitArgs(line)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		switch args[0] {
+		case "save":
+			s.save()
+		case "exit", "quit":
+			return s.save()
+		case "abort":
+			if s.changed {
+				fmt.Println("Discarded all changes since the last save")
+			}
+			return nil
+		case "help":
+			fmt.Println("Commands are the subcommands of towg without the file flag, like 'add buy milk', 'switch 3',")
+			fmt.Println("'redate 3 tomorrow' or 'print week'. 'save' writes the changes to the file, 'exit' saves and")
+			fmt.Println("leaves the shell and 'abort' leaves it without saving.")
+			app.Run([]string{app.Name, "help"})
+		default:
+			app.Run(append([]string{app.Name}, shellArgs(args, s.fileName)...))
+		}
+	}
+}
+
+// save writes the list of the session to its file if it was changed
+func (s *session) save() error {
+	if !s.changed {
+		return nil
+	}
+	list, err := saveLocked(s.list, s.fileName, joinMessages("shell", s.messages))
+	if saved(err) {
+		// The saved list contains the changes other programs made to the file in the meantime
+		s.list = list
+		s.changed = false
+		s.messages = nil
+		fmt.Println("Saved " + s.fileName)
+	}
+	if err != nil {
+		fmt.Println(err)
+	}
+	return err
+}
+
+// shellArgs turns the arguments typed into the shell into arguments for the subcommands of towg. The first
+// argument of print, switch, delete, redate, edit and add may be given without a flag, like in 'switch 3',
+// 'print week', 'edit 3 call mom' or 'add buy milk'. The file flag is set to the file of the session.
+func shellArgs(args []string, fileName string) []string {
+	command, rest := args[0], args[1:]
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		switch command {
+		case "print":
+			rest = append([]string{"-d"}, rest...)
+		case "switch", "delete":
+			rest = append([]string{"-n"}, rest...)
+		case "redate":
+			if len(rest) > 1 && !strings.HasPrefix(rest[1], "-") {
+				rest = append([]string{"-n", rest[0], "--newdate"}, rest[1:]...)
+			} else {
+				rest = append([]string{"-n"}, rest...)
+			}
+		case "add":
+			rest = []string{"-t", strings.Join(rest, " ")}
+		case "edit":
+			if len(rest) > 1 && !strings.HasPrefix(rest[1], "-") {
+				rest = []string{"-n", rest[0], "-t", strings.Join(rest[1:], " ")}
+			} else {
+				rest = append([]string{"-n"}, rest...)
+			}
+		}
+	}
+	return append([]string{command, "-f", fileName}, rest...)
+}
+
+// splitArgs splits a command line into arguments at spaces. Single and double quotes group words and a
+// backslash escapes the next character.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("Missing closing quote")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// completeShellLine completes the word before the cursor when tab is pressed. The first word is completed to a
+// command and the words after print, redate and the date flags to a date. If several completions are possible
+// their common prefix is used.
+func completeShellLine(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	start := strings.LastIndexAny(line[:pos], " \t") + 1
+	prefix := line[start:pos]
+	words := strings.Fields(line[:start])
+
+	var candidates []string
+	switch {
+	case len(words) == 0:
+		candidates = shellCommandNames()
+	case len(words) == 1 && words[0] == "print", words[len(words)-1] == "-d":
+		candidates = shellPeriods
+	case len(words) == 2 && words[0] == "redate", words[len(words)-1] == "--newdate":
+		candidates = shellDates
+	default:
+		return "", 0, false
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+
+	completion := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, completion) {
+			completion = completion[:len(completion)-1]
+		}
+	}
+	if len(matches) == 1 {
+		completion += " "
+	}
+	newLine := line[:start] + completion + line[pos:]
+	return newLine, start + len(completion), true
+}
+
+// shellCommandNames returns the names of all commands which can be used in the shell
+func shellCommandNames() []string {
+	names := append([]string{}, shellCommands...)
+	for _, c := range commands() {
+		if c.Name != "shell" && c.Name != "tui" && c.Name != "serve" {
+			names = append(names, c.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected []string
+	}{
+		{"", nil},
+		{"  switch   3 ", []string{"switch", "3"}},
+		{"add\tbuy milk", []string{"add", "buy", "milk"}},
+		{`add "buy milk" now`, []string{"add", "buy milk", "now"}},
+		{`add 'call "mom"'`, []string{"add", `call "mom"`}},
+		{`add "it's late"`, []string{"add", "it's late"}},
+		{`add buy\ milk`, []string{"add", "buy milk"}},
+		{`add \"milk\"`, []string{"add", `"milk"`}},
+		{`add "a \"b\""`, []string{"add", `a "b"`}},
+		{`add 'a\b'`, []string{"add", `a\b`}},
+		{`print ""`, []string{"print", ""}},
+		{`add buy"milk"`, []string{"add", "buymilk"}},
+	}
+	for _, test := range tests {
+		args, err := splitArgs(test.line)
+		assert.Equal(t, nil, err, "Error for splitting %q is not nil", test.line)
+		assert.Equal(t, test.expected, args, "Wrong arguments for %q", test.line)
+	}
+
+	for _, line := range []string{`add "buy milk`, `add 'buy milk`, `add "it's`} {
+		_, err := splitArgs(line)
+		assert.EqualError(t, err, "Missing closing quote", "Unterminated quote in %q is not reported", line)
+	}
+}
+
+func TestShellArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"print"}, []string{"print", "-f", "t.todo"}},
+		{[]string{"print", "week"}, []string{"print", "-f", "t.todo", "-d", "week"}},
+		{[]string{"print", "-d", "week"}, []string{"print", "-f", "t.todo", "-d", "week"}},
+		{[]string{"switch", "3"}, []string{"switch", "-f", "t.todo", "-n", "3"}},
+		{[]string{"delete", "1-3", "--done"}, []string{"delete", "-f", "t.todo", "-n", "1-3", "--done"}},
+		{[]string{"redate", "3"}, []string{"redate", "-f", "t.todo", "-n", "3"}},
+		{[]string{"redate", "3", "tomorrow"}, []string{"redate", "-f", "t.todo", "-n", "3", "--newdate", "tomorrow"}},
+		{[]string{"redate", "3", "--all"}, []string{"redate", "-f", "t.todo", "-n", "3", "--all"}},
+		{[]string{"add", "buy", "milk"}, []string{"add", "-f", "t.todo", "-t", "buy milk"}},
+		{[]string{"add", "buy milk"}, []string{"add", "-f", "t.todo", "-t", "buy milk"}},
+		{[]string{"add", "-t", "buy milk", "-d", "tomorrow"},
+			[]string{"add", "-f", "t.todo", "-t", "buy milk", "-d", "tomorrow"}},
+		{[]string{"edit", "3", "call", "mom"}, []string{"edit", "-f", "t.todo", "-n", "3", "-t", "call mom"}},
+		{[]string{"edit", "3", "--editor"}, []string{"edit", "-f", "t.todo", "-n", "3", "--editor"}},
+		{[]string{"search", "milk"}, []string{"search", "-f", "t.todo", "milk"}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, shellArgs(test.args, "t.todo"), "Wrong arguments for %q", test.args)
+	}
+}
+
+func TestCompleteShellLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		pos      int
+		expected string
+		ok       bool
+	}{
+		{"pri", 3, "print ", true},
+		{"s", 1, "s", true},
+		{"sw", 2, "switch ", true},
+		{"print to", 8, "print to", true},
+		{"print tom", 9, "print tomorrow ", true},
+		{"print w 3", 7, "print week  3", true},
+		{"switch 3 -d y", 13, "switch 3 -d yesterday ", true},
+		{"redate 3 tod", 12, "redate 3 today ", true},
+		{"redate -n 3 --newdate tom", 25, "redate -n 3 --newdate tomorrow ", true},
+		{"print x", 7, "", false},
+		{"switch 3", 8, "", false},
+		{"add buy m", 9, "", false},
+	}
+	for _, test := range tests {
+		line, pos, ok := completeShellLine(test.line, test.pos, '\t')
+		assert.Equal(t, test.ok, ok, "Wrong result for completing %q", test.line)
+		if ok {
+			assert.Equal(t, test.expected, line, "Wrong completion of %q", test.line)
+			assert.Equal(t, len(test.expected)-len(test.line)+test.pos, pos, "Wrong cursor after completing %q",
+				test.line)
+		}
+	}
+
+	_, _, ok := completeShellLine("pri", 3, 'a')
+	assert.Equal(t, false, ok, "Line is completed for another key than tab")
+}
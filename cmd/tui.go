@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/FChris/towg/task"
+	"github.com/urfave/cli"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"time"
+	"unicode/utf8"
+)
+
+func tuiCommand() cli.Command {
+	return cli.Command{
+		Name:  "tui",
+		Usage: "opens a full-screen interface to browse and edit the todos day by day",
+		Flags: []cli.Flag{
+			fileFlag(),
+			cli.StringFlag{
+				Name:  "date, d",
+				Usage: "day which is shown first. Allows dates as 'dd.mm.yy', or as 'yesterday', 'today', 'tomorrow'",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			fileName := c.String("file")
+			if fileName == "" {
+				fileName = fileNameDefault
+			}
+			var list task.DayList
+			if _, err := os.Stat(fileName); !os.IsNotExist(err) {
+				list, err = parseFromFile(fileName)
+				if err != nil {
+					fmt.Println(err)
+					return err
+				}
+			}
+
+			date := c.String("date")
+			if date == "" {
+				date = today
+			}
+			day, err := dateByDescription(date)
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+
+			fd := int(os.Stdin.Fd())
+			if !term.IsTerminal(fd) {
+				err = fmt.Errorf("tui needs to be run in a terminal")
+				fmt.Println(err)
+				return err
+			}
+			state, err := term.MakeRaw(fd)
+			if err != nil {
+				fmt.Println(err)
+				return err
+			}
+			defer term.Restore(fd, state)
+
+			m := newTUIModel(fileName, list, day)
+			m.colors = os.Getenv("NO_COLOR") == ""
+			return runTUI(os.Stdin, os.Stdout, fd, m)
+		},
+	}
+}
+
+// runTUI shows the model on the alternate screen of the terminal and passes all keys read from in to it until
+// the model quits
+func runTUI(in io.Reader, out io.Writer, fd int, m *tuiModel) error {
+	w := bufio.NewWriter(out)
+	fmt.Fprint(w, "\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Fprint(w, "\x1b[?25h\x1b[?1049l")
+		w.Flush()
+	}()
+
+	buf := make([]byte, 1024)
+	for !m.quit {
+		width, height, err := term.GetSize(fd)
+		if err != nil || width <= 0 || height <= 0 {
+			width, height = 80, 24
+		}
+		m.render(w, width, height)
+		if err = w.Flush(); err != nil {
+			return err
+		}
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return err
+		}
+		for _, k := range decodeKeys(buf[:n]) {
+			m.handleKey(k)
+			if m.quit {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// keyCode identifies the keys the tui reacts to. Keys which produce text are keyRune.
+type keyCode int
+
+const (
+	keyUnknown keyCode = iota
+	keyRune
+	keyEnter
+	keyBackspace
+	keyDelete
+	keyEscape
+	keyCtrlC
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyPageUp
+	keyPageDown
+)
+
+type key struct {
+	code keyCode
+	r    rune
+}
+
+// escapeSequences maps the ANSI escape sequences sent by terminals without the leading ESC [ or ESC O
+var escapeSequences = map[string]keyCode{
+	"A": keyUp, "B": keyDown, "C": keyRight, "D": keyLeft,
+	"H": keyHome, "F": keyEnd, "1~": keyHome, "4~": keyEnd, "7~": keyHome, "8~": keyEnd,
+	"3~": keyDelete, "5~": keyPageUp, "6~": keyPageDown,
+}
+
+// decodeKeys splits the bytes read from a terminal in raw mode into keys
+func decodeKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		k, n := decodeKey(b)
+		keys = append(keys, k)
+		b = b[n:]
+	}
+	return keys
+}
+
+// decodeKey returns the first key in b and the number of bytes it takes
+func decodeKey(b []byte) (key, int) {
+	switch b[0] {
+	case 3:
+		return key{code: keyCtrlC}, 1
+	case '\r', '\n':
+		return key{code: keyEnter}, 1
+	case 127, 8:
+		return key{code: keyBackspace}, 1
+	case 0x1b:
+		if len(b) < 3 || (b[1] != '[' && b[1] != 'O') {
+			return key{code: keyEscape}, 1
+		}
+		// The sequence ends with its first byte in the range @ to ~
+		i := 2
+		for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+			i++
+		}
+		if i == len(b) {
+			return key{code: keyUnknown}, len(b)
+		}
+		return key{code: escapeSequences[string(b[2:i+1])]}, i + 1
+	}
+
+	r, n := utf8.DecodeRune(b)
+	if r < 32 || r == utf8.RuneError {
+		return key{code: keyUnknown}, n
+	}
+	return key{code: keyRune, r: r}, n
+}
+
+// tuiNow returns the current date like the dates read by the parser
+func tuiNow() time.Time {
+	return ignoreTime(time.Now())
+}
@@ -0,0 +1,508 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/FChris/towg/parse"
+	"github.com/FChris/towg/task"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// tuiMode is what the keys typed into the tui currently do
+type tuiMode int
+
+const (
+	tuiBrowse tuiMode = iota
+	tuiAdd
+	tuiEdit
+	tuiFilter
+	tuiRedate
+	tuiConfirmDelete
+)
+
+const tuiHelp = "←→:day ↑↓:move space:done a:add e:edit r:redate d:delete /:filter t:today q:quit"
+
+// tuiRow is a todo shown in the tui together with its position in the list
+type tuiRow struct {
+	date time.Time
+	ind  int
+	todo task.Todo
+}
+
+// tuiModel is the state of the tui. It is independent of the terminal: keys are passed to handleKey and the
+// screen is drawn by render. Every change is saved right away through the same functions as the CLI commands.
+type tuiModel struct {
+	fileName string
+	list     task.DayList
+	colors   bool
+
+	// day is the day that is shown unless a filter is set. With a filter the matching todos of all days are shown.
+	day    time.Time
+	filter string
+	rows   []tuiRow
+	cursor int
+	offset int
+
+	mode    tuiMode
+	input   []rune
+	pick    time.Time
+	message string
+	quit    bool
+}
+
+func newTUIModel(fileName string, list task.DayList, day time.Time) *tuiModel {
+	m := &tuiModel{fileName: fileName, list: list, day: ignoreTime(day)}
+	m.refresh()
+	return m
+}
+
+// refresh collects the rows for the current day or filter and keeps the cursor within them
+func (m *tuiModel) refresh() {
+	m.rows = m.rows[:0]
+	if m.filter == "" {
+		for i, todo := range m.list.DayByDate(m.day).Todos {
+			m.rows = append(m.rows, tuiRow{date: m.day, ind: i, todo: todo})
+		}
+	} else {
+		filter := strings.ToLower(m.filter)
+		for _, day := range m.list {
+			for i, todo := range day.Todos {
+				if strings.Contains(strings.ToLower(todo.Text()), filter) {
+					m.rows = append(m.rows, tuiRow{date: ignoreTime(day.Date), ind: i, todo: todo})
+				}
+			}
+		}
+	}
+
+	if m.cursor >= len(m.rows) {
+		m.cursor = len(m.rows) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// selected returns the row under the cursor
+func (m *tuiModel) selected() (tuiRow, bool) {
+	if m.cursor < len(m.rows) {
+		return m.rows[m.cursor], true
+	}
+	return tuiRow{}, false
+}
+
+// selectTodo moves the cursor to the todo with the given text on the given date if it is shown
+func (m *tuiModel) selectTodo(date time.Time, text string) {
+	for i, row := range m.rows {
+		if row.date.Equal(date) && row.todo.Text() == text {
+			m.cursor = i
+			return
+		}
+	}
+}
+
+// showDay switches to the given day and moves the cursor to its first todo
+func (m *tuiModel) showDay(day time.Time) {
+	m.day = day
+	m.cursor = 0
+	m.refresh()
+}
+
+// showNextDayWithTodos switches to the closest day before or after the current one which has todos
+func (m *tuiModel) showNextDayWithTodos(later bool) {
+	var next time.Time
+	for _, day := range m.list {
+		date := ignoreTime(day.Date)
+		if len(day.Todos) == 0 || (later && !date.After(m.day)) || (!later && !date.Before(m.day)) {
+			continue
+		}
+		if next.IsZero() || (later && date.Before(next)) || (!later && date.After(next)) {
+			next = date
+		}
+	}
+	if !next.IsZero() {
+		m.showDay(next)
+	}
+}
+
+// listCopy returns a copy of the list to change, so that m.list is only replaced once the change is saved
+func (m *tuiModel) listCopy() task.DayList {
+	return task.NewStoreInOrder(m.list, fileOrder).DayList()
+}
+
+// commit saves the changed list and selects the todo with the given text on the given date. message is
+// shown in the status line and change is the commit message in git mode.
+func (m *tuiModel) commit(list task.DayList, date time.Time, text string, message string, change string) {
+	list, err := saveLocked(list, m.fileName, change)
+	if saved(err) {
+		m.list = list
+	}
+	m.message = message
+	if err != nil {
+		m.message = err.Error()
+	}
+	m.refresh()
+	m.selectTodo(date, text)
+}
+
+func (m *tuiModel) handleKey(k key) {
+	if k.code == keyCtrlC {
+		m.quit = true
+		return
+	}
+
+	switch m.mode {
+	case tuiBrowse:
+		m.message = ""
+		m.browse(k)
+	case tuiAdd, tuiEdit, tuiFilter:
+		m.editLine(k)
+	case tuiRedate:
+		m.pickDate(k)
+	case tuiConfirmDelete:
+		m.mode = tuiBrowse
+		row, ok := m.selected()
+		if ok && k.code == keyRune && (k.r == 'y' || k.r == 'Y') {
+			list := m.listCopy()
+			if err := list.DeleteTodo(row.date, row.ind); err != nil {
+				m.message = err.Error()
+				return
+			}
+			m.commit(list, row.date, "", "Deleted "+row.todo.Description,
+				changeMessage("delete", row.todo, row.date, ""))
+		}
+	}
+}
+
+func (m *tuiModel) browse(k key) {
+	row, ok := m.selected()
+	code, r := k.code, k.r
+	if code == keyRune {
+		// Letters work like the arrow keys for terminals without them
+		switch r {
+		case 'h':
+			code = keyLeft
+		case 'l':
+			code = keyRight
+		case 'k':
+			code = keyUp
+		case 'j':
+			code = keyDown
+		case '[':
+			code = keyPageUp
+		case ']':
+			code = keyPageDown
+		}
+	}
+
+	switch code {
+	case keyLeft:
+		m.showDay(m.day.AddDate(0, 0, -1))
+	case keyRight:
+		m.showDay(m.day.AddDate(0, 0, 1))
+	case keyPageUp:
+		m.showNextDayWithTodos(false)
+	case keyPageDown:
+		m.showNextDayWithTodos(true)
+	case keyUp:
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case keyDown:
+		if m.cursor < len(m.rows)-1 {
+			m.cursor++
+		}
+	case keyHome:
+		m.cursor = 0
+	case keyEnd:
+		if m.cursor = len(m.rows) - 1; m.cursor < 0 {
+			m.cursor = 0
+		}
+	case keyEnter:
+		m.toggle(row, ok)
+	case keyDelete:
+		if ok {
+			m.mode = tuiConfirmDelete
+		}
+	case keyEscape:
+		m.filter = ""
+		m.refresh()
+	case keyRune:
+		switch r {
+		case ' ', 'x':
+			m.toggle(row, ok)
+		case 'a':
+			m.mode = tuiAdd
+			m.input = nil
+		case 'e':
+			if ok {
+				m.mode = tuiEdit
+				m.input = []rune(row.todo.Text())
+			}
+		case 'r':
+			if ok {
+				m.mode = tuiRedate
+				m.pick = row.date
+			}
+		case 'd':
+			if ok {
+				m.mode = tuiConfirmDelete
+			}
+		case '/':
+			m.mode = tuiFilter
+			m.input = []rune(m.filter)
+		case 't':
+			m.filter = ""
+			m.showDay(tuiNow())
+		case 'q':
+			m.quit = true
+		}
+	}
+}
+
+func (m *tuiModel) toggle(row tuiRow, ok bool) {
+	if !ok {
+		return
+	}
+	list := m.listCopy()
+	switchTodoStatus(list, row.date, row.ind)
+	m.commit(list, row.date, row.todo.Text(), "",
+		changeMessage("switch", row.todo, row.date, statusName(!row.todo.Complete)))
+}
+
+// editLine handles the keys while text is typed for adding, editing or filtering
+func (m *tuiModel) editLine(k key) {
+	switch k.code {
+	case keyRune:
+		m.input = append(m.input, k.r)
+	case keyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case keyEscape:
+		if m.mode == tuiFilter {
+			m.filter = ""
+			m.refresh()
+		}
+		m.mode = tuiBrowse
+		return
+	case keyEnter:
+		mode := m.mode
+		m.mode = tuiBrowse
+		m.finishLine(mode, strings.TrimSpace(string(m.input)))
+		return
+	}
+
+	// The filter is applied while it is typed
+	if m.mode == tuiFilter {
+		m.filter = string(m.input)
+		m.cursor = 0
+		m.refresh()
+	}
+}
+
+func (m *tuiModel) finishLine(mode tuiMode, text string) {
+	switch mode {
+	case tuiAdd:
+		if text == "" {
+			return
+		}
+		list, err := addTodoFromDesc(m.listCopy(), text, m.day.Format(parse.Timeformat))
+		if err != nil {
+			m.message = err.Error()
+			return
+		}
+		todo := task.ParseTodo(text, false)
+		m.commit(list, m.day, todo.Text(), "Added", changeMessage("add", todo, m.day, ""))
+	case tuiEdit:
+		row, ok := m.selected()
+		if !ok {
+			return
+		}
+		list, err := editTodo(m.listCopy(), row.date, row.ind, text)
+		if err != nil {
+			m.message = err.Error()
+			return
+		}
+		todo := task.ParseTodo(text, false)
+		m.commit(list, row.date, todo.Text(), "Saved", changeMessage("edit", row.todo, row.date, todo.Description))
+	case tuiFilter:
+		m.filter = text
+		m.refresh()
+	}
+}
+
+// pickDate handles the keys of the date picker for redating the selected todo
+func (m *tuiModel) pickDate(k key) {
+	switch k.code {
+	case keyLeft:
+		m.pick = m.pick.AddDate(0, 0, -1)
+	case keyRight:
+		m.pick = m.pick.AddDate(0, 0, 1)
+	case keyUp:
+		m.pick = m.pick.AddDate(0, 0, -7)
+	case keyDown:
+		m.pick = m.pick.AddDate(0, 0, 7)
+	case keyPageUp:
+		m.pick = m.pick.AddDate(0, -1, 0)
+	case keyPageDown:
+		m.pick = m.pick.AddDate(0, 1, 0)
+	case keyEscape:
+		m.mode = tuiBrowse
+	case keyRune:
+		if k.r == 't' {
+			m.pick = tuiNow()
+		}
+	case keyEnter:
+		m.mode = tuiBrowse
+		row, ok := m.selected()
+		if !ok || m.pick.Equal(row.date) {
+			return
+		}
+		list, err := changeDateOfTodo(m.listCopy(), row.date, row.ind, m.pick)
+		if err != nil {
+			m.message = err.Error()
+			return
+		}
+		m.commit(list, m.pick, row.todo.Text(), "Moved to "+relativeDateLabel(m.pick, tuiNow()),
+			changeMessage("redate", row.todo, row.date, m.pick.Format(parse.Timeformat)))
+	}
+}
+
+// render draws the whole screen, which is width columns wide and height lines high
+func (m *tuiModel) render(w io.Writer, width, height int) {
+	if height < 4 {
+		height = 4
+	}
+	current := tuiNow()
+	var lines []string
+
+	lines = append(lines, m.paint("heading", truncate("towg  "+m.fileName, width)))
+	if m.filter != "" {
+		lines = append(lines, truncate(fmt.Sprintf("Filter: %s  (%d todos)", m.filter, len(m.rows)), width))
+	} else {
+		done := 0
+		for _, row := range m.rows {
+			if row.todo.Complete {
+				done++
+			}
+		}
+		lines = append(lines, truncate(fmt.Sprintf("%s %s  %d/%d done",
+			relativeDateLabel(m.day, current), m.day.Format(parse.Timeformat), done, len(m.rows)), width))
+	}
+	lines = append(lines, "")
+
+	body := height - len(lines) - 2
+	if m.mode == tuiRedate {
+		lines = append(lines, m.calendar(current)...)
+	} else if len(m.rows) == 0 {
+		lines = append(lines, "  No todos. Press a to add one.")
+	} else {
+		if m.cursor < m.offset {
+			m.offset = m.cursor
+		}
+		if body > 0 && m.cursor >= m.offset+body {
+			m.offset = m.cursor - body + 1
+		}
+		for i := m.offset; i < len(m.rows) && i < m.offset+body; i++ {
+			lines = append(lines, m.row(i, width, current))
+		}
+	}
+
+	for len(lines) < height-2 {
+		lines = append(lines, "")
+	}
+	lines = append(lines[:height-2], truncate(m.statusLine(), width), m.paint("help", truncate(tuiHelp, width)))
+
+	fmt.Fprint(w, "\x1b[H")
+	for i, line := range lines {
+		fmt.Fprint(w, line, "\x1b[K")
+		if i < len(lines)-1 {
+			fmt.Fprint(w, "\r\n")
+		}
+	}
+	fmt.Fprint(w, "\x1b[J")
+}
+
+// row returns the line for the i-th row
+func (m *tuiModel) row(i, width int, current time.Time) string {
+	row := m.rows[i]
+	text := strings.Replace(row.todo.String(), "\n", " ", -1)
+	if m.filter != "" {
+		text = row.date.Format(parse.Timeformat) + "  " + text
+	}
+
+	text = truncate("  "+text, width)
+	if i == m.cursor {
+		return "\x1b[7m" + text + ansiReset
+	}
+	return m.paint(todoStatus(row.todo, row.date, current), text)
+}
+
+// calendar returns the lines of the date picker showing the month of the picked date
+func (m *tuiModel) calendar(current time.Time) []string {
+	lines := []string{"  " + m.pick.Format("January 2006"), "  Mo Tu We Th Fr Sa Su"}
+
+	first := m.pick.AddDate(0, 0, 1-m.pick.Day())
+	line := "  " + strings.Repeat("   ", (int(first.Weekday())+6)%7)
+	for date := first; date.Month() == first.Month(); date = date.AddDate(0, 0, 1) {
+		cell := fmt.Sprintf("%2d", date.Day())
+		switch {
+		case date.Equal(m.pick):
+			cell = "\x1b[7m" + cell + ansiReset
+		case date.Equal(current):
+			cell = m.paint("heading", cell)
+		}
+		line += cell + " "
+		if date.Weekday() == time.Sunday {
+			lines = append(lines, line)
+			line = "  "
+		}
+	}
+	if line != "  " {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// statusLine returns the prompt of the current mode or the last message
+func (m *tuiModel) statusLine() string {
+	switch m.mode {
+	case tuiAdd:
+		return "Add: " + string(m.input) + "_"
+	case tuiEdit:
+		return "Edit: " + string(m.input) + "_"
+	case tuiFilter:
+		return "/" + string(m.input) + "_"
+	case tuiRedate:
+		return "Redate to " + relativeDateLabel(m.pick, tuiNow()) + " " + m.pick.Format(parse.Timeformat) +
+			"  (arrows pick, enter confirms, esc cancels)"
+	case tuiConfirmDelete:
+		row, _ := m.selected()
+		return fmt.Sprintf("Delete %q? (y/n)", row.todo.Description)
+	}
+	return m.message
+}
+
+// paint colors s for the given status if colors are enabled
+func (m *tuiModel) paint(status, s string) string {
+	if status == "help" && m.colors {
+		return "\x1b[2m" + s + ansiReset
+	}
+	if code, ok := ansiColors[status]; ok && m.colors {
+		return code + s + ansiReset
+	}
+	return s
+}
+
+// truncate shortens s to at most width runes
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width])
+}
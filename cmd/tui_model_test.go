@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/FChris/towg/task"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTUIModel_FailedSaveKeepsList(t *testing.T) {
+	date := time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC)
+	list := task.DayList{{Date: date, Todos: task.TodoList{{Description: "Todo 1"}, {Description: "Todo 2"}}}}
+	// The lock file cannot be created in a missing directory, so every save fails
+	m := newTUIModel(filepath.Join("missing", "dir", "test.todo"), list, date)
+
+	m.handleKey(key{code: keyRune, r: 'x'})
+	assert.NotEqual(t, "", m.message, "Failed save of a switched todo is not reported")
+	assert.Equal(t, false, m.list[0].Todos[0].Complete, "Todo is switched although it was not saved")
+
+	m.handleKey(key{code: keyRune, r: 'd'})
+	m.handleKey(key{code: keyRune, r: 'y'})
+	assert.NotEqual(t, "", m.message, "Failed save of a deleted todo is not reported")
+	assert.Equal(
+		t,
+		task.TodoList{{Description: "Todo 1"}, {Description: "Todo 2"}},
+		m.list[0].Todos,
+		"Todo is deleted although it was not saved")
+}
+
+func TestTUIModel_EndOfEmptyDay(t *testing.T) {
+	date := time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC)
+	m := newTUIModel("test.todo", nil, date)
+
+	m.handleKey(key{code: keyEnd})
+	assert.Equal(t, 0, m.cursor, "Cursor is outside of the rows of an empty day")
+	_, ok := m.selected()
+	assert.Equal(t, false, ok, "A todo is selected on an empty day")
+}
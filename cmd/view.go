@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/FChris/towg/task"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// view is the list of todos shown by the last print of a todo file. Commands which select a todo by number
+// without a date use it, so that the number refers to what was on screen even if the file was sorted
+// differently in the meantime.
+type view struct {
+	Period string     `json:"period"`
+	Todos  []viewTodo `json:"todos"`
+}
+
+// viewTodo identifies a printed todo by its day and text
+type viewTodo struct {
+	Date string `json:"date"`
+	Text string `json:"text"`
+}
+
+// viewFileName returns the name of the file which stores the last view of the given todo file
+func viewFileName(fileName string) string {
+	return filepath.Join(filepath.Dir(fileName), "."+filepath.Base(fileName)+".view")
+}
+
+// newView returns the view of the list printed for the given period
+func newView(period string, list task.DayList) *view {
+	v := &view{Period: period}
+	for _, day := range list {
+		for _, todo := range day.Todos {
+			v.Todos = append(v.Todos, viewTodo{Date: day.Date.Format(task.DateFormat), Text: todo.Text()})
+		}
+	}
+	return v
+}
+
+// save remembers the view as last view of the todo file
+func (v *view) save(fileName string) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(viewFileName(fileName), data, 0600)
+}
+
+// loadView returns the last view of the todo file or nil if it was never printed
+func loadView(fileName string) (*view, error) {
+	data, err := ioutil.ReadFile(viewFileName(fileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Error while reading last view: %s", err)
+	}
+
+	v := &view{}
+	if err = json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("Error while reading last view: %s", err)
+	}
+	return v, nil
+}
+
+// redate moves the n-th todo of the view to the given date, so that its number stays valid
+func (v *view) redate(n int, date time.Time) {
+	if n >= 1 && n <= len(v.Todos) {
+		v.Todos[n-1].Date = date.Format(task.DateFormat)
+	}
+}
+
+// edit changes the text of the n-th todo of the view, so that its number stays valid
+func (v *view) edit(n int, text string) {
+	if n >= 1 && n <= len(v.Todos) {
+		v.Todos[n-1].Text = text
+	}
+}
+
+// move moves the n-th todo of the view to the position of the todo with number to, so that the numbers follow the
+// new order of the day
+func (v *view) move(n, to int) {
+	if n >= 1 && n <= len(v.Todos) && to >= 1 && to <= len(v.Todos) {
+		todo := v.Todos[n-1]
+		if n < to {
+			copy(v.Todos[n-1:to-1], v.Todos[n:to])
+		} else {
+			copy(v.Todos[to:n], v.Todos[to-1:n-1])
+		}
+		v.Todos[to-1] = todo
+	}
+}
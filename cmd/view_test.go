@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"github.com/FChris/towg/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testApp returns the app with all commands but without the global flags, so that the config of the user is not read
+func testApp() *cli.App {
+	app := cli.NewApp()
+	app.Name = "towg"
+	app.Commands = commands()
+	return app
+}
+
+func TestView_SaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "towg")
+	assert.Equal(t, nil, err, "Error for creating the directory is not nil")
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "test.todo")
+
+	v, err := loadView(fileName)
+	assert.Equal(t, nil, err, "Error for loading a missing view is not nil")
+	assert.Nil(t, v, "View is returned for a file which was never printed")
+
+	date := time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC)
+	list := task.DayList{
+		{Date: date, Todos: task.TodoList{{Description: "Todo 1", Complete: true}, {Description: "Todo 2", Priority: "A"}}},
+		{Date: date.AddDate(0, 0, -1), Todos: task.TodoList{{Description: "Todo 3"}}},
+	}
+	expected := &view{Period: "week", Todos: []viewTodo{
+		{Date: "2017-07-17", Text: "Todo 1"}, {Date: "2017-07-17", Text: "(A) Todo 2"},
+		{Date: "2017-07-16", Text: "Todo 3"},
+	}}
+	assert.Equal(t, expected, newView("week", list), "View does not list the todos in the printed order")
+	assert.Equal(t, nil, newView("week", list).save(fileName), "Error for saving the view is not nil")
+
+	v, err = loadView(fileName)
+	assert.Equal(t, nil, err, "Error for loading the view is not nil")
+	assert.Equal(t, expected, v, "Loaded view differs from the saved one")
+
+	assert.Equal(t, nil, ioutil.WriteFile(viewFileName(fileName), []byte("{"), 0600), "Error for writing is not nil")
+	_, err = loadView(fileName)
+	assert.NotEqual(t, nil, err, "Broken view file is not reported")
+}
+
+func TestView_Changes(t *testing.T) {
+	entries := func(texts ...string) []viewTodo {
+		var todos []viewTodo
+		for _, text := range texts {
+			todos = append(todos, viewTodo{Date: "2017-07-17", Text: text})
+		}
+		return todos
+	}
+
+	v := &view{Todos: entries("A", "B", "C", "D")}
+	v.move(4, 2)
+	assert.Equal(t, entries("A", "D", "B", "C"), v.Todos, "Todo is not moved up")
+	v.move(1, 3)
+	assert.Equal(t, entries("D", "B", "A", "C"), v.Todos, "Todo is not moved down")
+	v.move(1, 5)
+	v.move(0, 1)
+	assert.Equal(t, entries("D", "B", "A", "C"), v.Todos, "Move to a missing number changed the view")
+
+	v.edit(2, "(A) B")
+	v.edit(5, "E")
+	assert.Equal(t, entries("D", "(A) B", "A", "C"), v.Todos, "Edit does not change only the text of the number")
+
+	v.redate(3, time.Date(2017, 7, 18, 0, 0, 0, 0, time.UTC))
+	v.redate(0, time.Date(2017, 7, 18, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, viewTodo{Date: "2017-07-18", Text: "A"}, v.Todos[2], "Redate does not change the date")
+	assert.Equal(t, entries("D", "(A) B"), v.Todos[:2], "Redate changed other todos")
+}
+
+func TestView_NumbersAfterChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "towg")
+	assert.Equal(t, nil, err, "Error for creating the directory is not nil")
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "test.todo")
+
+	order := fileOrder
+	defer func() { fileOrder = order }()
+	fileOrder.Todos = task.ManualOrder
+
+	data := "\n# 18.07.17\n\n- [ ] Todo 1  \n- [ ] Todo 2  \n\n# 17.07.17\n\n- [ ] Todo 3  \n- [ ] Todo 4  \n"
+	assert.Equal(t, nil, ioutil.WriteFile(fileName, []byte(data), 0600), "Error for writing the file is not nil")
+
+	app := testApp()
+	run := func(args ...string) {
+		err := app.Run(append([]string{"towg"}, args...))
+		assert.Equal(t, nil, err, "Error for running %v is not nil", args)
+	}
+	// numbers returns the texts of the todos of the last view in the order of their numbers
+	numbers := func() []string {
+		list, err := loadList(fileName)
+		assert.Equal(t, nil, err, "Error for loading the file is not nil")
+		numbered, fromView, err := numberedTodosOfPeriod(list, fileName, "")
+		assert.Equal(t, nil, err, "Error for reading the view is not nil")
+		assert.True(t, fromView, "Numbers do not come from the view")
+		var texts []string
+		for _, s := range numbered {
+			if s.missing {
+				texts = append(texts, "missing "+s.todo.Text())
+			} else {
+				texts = append(texts, s.todo.String())
+			}
+		}
+		return texts
+	}
+
+	run("print", "-f", fileName, "-d", "-")
+	assert.Equal(t, []string{"- [ ] Todo 1", "- [ ] Todo 2", "- [ ] Todo 3", "- [ ] Todo 4"}, numbers(),
+		"Numbers of the view differ from the printed list")
+
+	run("redate", "-f", fileName, "-n", "1", "--newdate", "17.07.17")
+	run("switch", "-f", fileName, "-n", "1")
+	assert.Equal(t, []string{"- [x] Todo 1", "- [ ] Todo 2", "- [ ] Todo 3", "- [ ] Todo 4"}, numbers(),
+		"Number of a redated todo is not valid anymore")
+
+	run("edit", "-f", fileName, "-n", "3", "-t", "(A) Todo 3")
+	run("switch", "-f", fileName, "-n", "3")
+	assert.Equal(t, []string{"- [x] Todo 1", "- [ ] Todo 2", "- [x] (A) Todo 3", "- [ ] Todo 4"}, numbers(),
+		"Number of an edited todo is not valid anymore")
+
+	run("move", "-f", fileName, "-n", "4", "--to", "3")
+	run("switch", "-f", fileName, "-n", "4")
+	assert.Equal(t, []string{"- [x] Todo 1", "- [ ] Todo 2", "- [ ] Todo 4", "- [ ] (A) Todo 3"}, numbers(),
+		"Numbers do not follow the moved todo")
+
+	run("delete", "-f", fileName, "-n", "2")
+	assert.Equal(t, []string{"- [x] Todo 1", "missing Todo 2", "- [ ] Todo 4", "- [ ] (A) Todo 3"}, numbers(),
+		"Numbers of the other todos change when a todo is deleted")
+
+	written, err := ioutil.ReadFile(fileName)
+	assert.Equal(t, nil, err, "Error for reading the file is not nil")
+	assert.Equal(t, "\n# 18.07.17\n\n\n# 17.07.17\n\n- [ ] Todo 4  \n- [ ] (A) Todo 3  \n- [x] Todo 1  \n",
+		string(written), "File differs after the changes")
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/FChris/towg/parse"
+	"github.com/FChris/towg/task"
+	"golang.org/x/term"
+	"os"
+	"time"
+)
+
+// watchInterval is how often print --watch checks whether the todo file changed
+const watchInterval = 500 * time.Millisecond
+
+// watchDayList prints the period of the list returned by load again whenever the todo file changes and at midnight,
+// until towg is interrupted. Todos which were added or changed since the previous output are highlighted and
+// removed todos are listed below the list.
+//
+// The file is polled instead of using notifications of the operating system, since editors often replace the file
+// instead of writing to it and towg does the same when it saves.
+func watchDayList(fileName, period string, load func() (task.DayList, error), opts printOptions) error {
+	clearScreen := term.IsTerminal(int(os.Stdout.Fd()))
+
+	var previous task.DayList
+	var modTime, day time.Time
+	size := int64(-1)
+	first := true
+	for ; ; time.Sleep(watchInterval) {
+		info, err := os.Stat(fileName)
+		if err != nil {
+			if first {
+				fmt.Println(err)
+				return err
+			}
+			// The file is missing for a moment while it is replaced
+			continue
+		}
+
+		current := ignoreTime(time.Now())
+		if !first && info.ModTime().Equal(modTime) && info.Size() == size && current.Equal(day) {
+			continue
+		}
+		modTime, size, day = info.ModTime(), info.Size(), current
+
+		list, err := load()
+		if err != nil {
+			// An editor might be in the middle of writing the file, so it is read again with its next change
+			fmt.Println(err)
+			if first {
+				return err
+			}
+			continue
+		}
+		periodList, err := printPeriod(list, period)
+		if err != nil {
+			fmt.Println(err)
+			return err
+		}
+
+		var buf bytes.Buffer
+		if clearScreen {
+			buf.WriteString("\x1b[H\x1b[2J")
+		}
+		fmt.Fprintf(&buf, "Watching %s, updated at %s\n", fileName, time.Now().Format("15:04:05"))
+		opts.previous, opts.diff = previous, !first
+		if err = printDayList(&buf, periodList, opts); err != nil {
+			fmt.Println(err)
+			return err
+		}
+		if !first {
+			for _, removed := range removedTodos(previous, periodList) {
+				fmt.Fprintln(&buf, "Removed: "+removed)
+			}
+		}
+		if _, err = os.Stdout.Write(buf.Bytes()); err != nil {
+			return err
+		}
+
+		newView(period, periodList).save(fileName)
+		previous = periodList
+		first = false
+	}
+}
+
+// removedTodos describes the todos of previous which are not part of list anymore
+func removedTodos(previous, list task.DayList) []string {
+	var removed []string
+	for _, day := range previous {
+		todos := list.DayByDate(day.Date).Todos
+		for _, todo := range day.Todos {
+			found := false
+			for _, t := range todos {
+				if t.Text() == todo.Text() {
+					found = true
+					break
+				}
+			}
+			if !found {
+				removed = append(removed, day.Date.Format(parse.Timeformat)+" "+todo.Text())
+			}
+		}
+	}
+	return removed
+}
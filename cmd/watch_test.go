@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"github.com/FChris/towg/task"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// watchTestLists returns the list of the previous output of print --watch and the list after Todo A was switched,
+// Todo B was given a priority, Todo C was deleted, Todo D was moved to the next day and Todo E was added
+func watchTestLists() (previous, list task.DayList) {
+	date := time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC)
+	previous = task.DayList{
+		{Date: date, Todos: task.TodoList{{Description: "Todo A"}, {Description: "Todo B"}, {Description: "Todo C"},
+			{Description: "Todo D"}}},
+	}
+	list = task.DayList{
+		{Date: date.AddDate(0, 0, 1), Todos: task.TodoList{{Description: "Todo D"}}},
+		{Date: date, Todos: task.TodoList{{Description: "Todo A", Complete: true}, {Description: "Todo B", Priority: "A"},
+			{Description: "Todo E"}}},
+	}
+	return previous, list
+}
+
+func TestRemovedTodos(t *testing.T) {
+	previous, list := watchTestLists()
+	assert.Equal(
+		t,
+		[]string{"17.07.17 Todo B", "17.07.17 Todo C", "17.07.17 Todo D"},
+		removedTodos(previous, list),
+		"Removed todos are wrong")
+	assert.Empty(t, removedTodos(list, list), "Todos of an unchanged list are removed")
+	assert.Empty(t, removedTodos(nil, list), "Todos are removed from an empty list")
+}
+
+func TestPrintDayList_Diff(t *testing.T) {
+	previous, list := watchTestLists()
+
+	var out bytes.Buffer
+	err := printDayList(&out, list, printOptions{format: "compact", previous: previous, diff: true})
+	assert.Equal(t, nil, err, "Error for printing is not nil")
+	assert.Equal(
+		t,
+		"  1 Tue 18 Jul 2017 - [ ] Todo D (new)\n"+
+			"  2 Mon 17 Jul 2017 - [x] Todo A (changed)\n"+
+			"  3 Mon 17 Jul 2017 - [ ] (A) Todo B (new)\n"+
+			"  4 Mon 17 Jul 2017 - [ ] Todo E (new)\n",
+		out.String(),
+		"Changes are not marked")
+
+	out.Reset()
+	err = printDayList(&out, list, printOptions{format: "compact", colors: true, previous: previous, diff: true})
+	assert.Equal(t, nil, err, "Error for printing is not nil")
+	assert.Contains(t, out.String(), "  2 Mon 17 Jul 2017 \x1b[7m\x1b[32m- [x] Todo A\x1b[0m\x1b[0m\n",
+		"Changes are not highlighted with colors")
+
+	out.Reset()
+	err = printDayList(&out, list, printOptions{format: "compact", previous: list, diff: true})
+	assert.Equal(t, nil, err, "Error for printing is not nil")
+	assert.NotContains(t, out.String(), "(new)", "Todos of an unchanged list are marked")
+	assert.NotContains(t, out.String(), "(changed)", "Todos of an unchanged list are marked")
+
+	out.Reset()
+	err = printDayList(&out, list, printOptions{format: "compact", previous: previous})
+	assert.Equal(t, nil, err, "Error for printing is not nil")
+	assert.NotContains(t, out.String(), "(new)", "Changes are marked without diff")
+}
@@ -0,0 +1,73 @@
+// Package convert reads and writes todo lists in the file formats of other tools
+package convert
+
+import (
+	"fmt"
+	"github.com/FChris/towg/task"
+	"io"
+	"sort"
+	"time"
+)
+
+// Format describes how a task.DayList is read from and written to a specific file format
+type Format struct {
+	Name string
+
+	// Encode writes the list to w. It is nil if the format can only be read.
+	Encode func(w io.Writer, list task.DayList, opts Options) error
+
+	// Decode reads a DayList from r. It is nil if the format can only be written.
+	Decode func(r io.Reader) (task.DayList, error)
+}
+
+// Options configure how a DayList is written. Formats ignore the options which do not apply to them.
+type Options struct {
+	// PageBreak is "day" or "week" to start a new page for every day or week in paged formats like pdf.
+	// If it is empty pages are only broken when they are full.
+	PageBreak string
+
+	// Pocket selects a compact layout for small paper in paged formats
+	Pocket bool
+}
+
+var formats = map[string]Format{}
+
+// now returns the current time. It is replaced in tests.
+var now = time.Now
+
+func register(f Format) {
+	formats[f.Name] = f
+}
+
+// ByName returns the format with the given name
+func ByName(name string) (Format, error) {
+	f, ok := formats[name]
+	if !ok {
+		return f, fmt.Errorf("unknown format %q, expected one of %v", name, Names())
+	}
+	return f, nil
+}
+
+// Names returns the names of all formats in alphabetical order
+func Names() []string {
+	var names []string
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// chronological returns a copy of the list with the oldest day first, whatever the order of the list is
+func chronological(list task.DayList) task.DayList {
+	days := make(task.DayList, len(list))
+	copy(days, list)
+	task.OldestFirst.Sort(days)
+	return days
+}
+
+// today returns the current date without time like the dates read by the parser
+func today() time.Time {
+	t := now()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
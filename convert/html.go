@@ -0,0 +1,191 @@
+package convert
+
+import (
+	"github.com/FChris/towg/parse"
+	"github.com/FChris/towg/task"
+	"html/template"
+	"io"
+	"time"
+)
+
+// init registers the html format. It writes a single self-contained page with a month calendar showing the
+// completion of every day, followed by a section per day. Selecting a day in the calendar shows only its section
+// and the buttons at the top filter the todos by status. Without JavaScript all days and todos are shown.
+func init() {
+	register(Format{Name: "html", Encode: encodeHTML})
+}
+
+type htmlPage struct {
+	Title  string
+	Months []htmlMonth
+	Days   []htmlDay
+}
+
+type htmlMonth struct {
+	Title string
+	Weeks [][7]htmlCell
+}
+
+// htmlCell is a single day of the calendar. Day is 0 for cells outside of the month.
+type htmlCell struct {
+	Day     int
+	ID      string
+	Total   int
+	Done    int
+	Percent int
+}
+
+type htmlDay struct {
+	ID      string
+	Title   string
+	Done    int
+	Todos   task.TodoList
+	Percent int
+}
+
+func encodeHTML(w io.Writer, list task.DayList, _ Options) error {
+	page := htmlPage{Title: "towg"}
+
+	list = chronological(list)
+	days := make(map[string]htmlDay)
+	for _, d := range list {
+		day := htmlDay{
+			ID:    "day-" + d.Date.Format(task.DateFormat),
+			Title: d.Date.Format("Monday, " + parse.Timeformat),
+			Todos: d.Todos,
+		}
+		for _, todo := range day.Todos {
+			if todo.Complete {
+				day.Done++
+			}
+		}
+		day.Percent = percent(day.Done, len(day.Todos))
+		days[day.ID] = day
+		page.Days = append(page.Days, day)
+	}
+
+	if len(list) > 0 {
+		first := list[0].Date
+		last := list[len(list)-1].Date
+		page.Title = "towg " + first.Format(parse.Timeformat) + " - " + last.Format(parse.Timeformat)
+		for month := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(last); month = month.AddDate(0, 1, 0) {
+			page.Months = append(page.Months, calendarMonth(month, days))
+		}
+	}
+
+	return htmlTemplate.Execute(w, page)
+}
+
+// calendarMonth returns the weeks of the month starting at the given date, with weeks starting on Monday
+func calendarMonth(month time.Time, days map[string]htmlDay) htmlMonth {
+	m := htmlMonth{Title: month.Format("January 2006")}
+
+	var week [7]htmlCell
+	for date := month; date.Month() == month.Month(); date = date.AddDate(0, 0, 1) {
+		weekday := (int(date.Weekday()) + 6) % 7
+		if weekday == 0 && date.Day() > 1 {
+			m.Weeks = append(m.Weeks, week)
+			week = [7]htmlCell{}
+		}
+
+		cell := htmlCell{Day: date.Day()}
+		if day, ok := days["day-"+date.Format(task.DateFormat)]; ok {
+			cell.ID = day.ID
+			cell.Total = len(day.Todos)
+			cell.Done = day.Done
+			cell.Percent = day.Percent
+		}
+		week[weekday] = cell
+	}
+	m.Weeks = append(m.Weeks, week)
+	return m
+}
+
+func percent(part, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return part * 100 / total
+}
+
+var htmlTemplate = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 56em; margin: 0 auto; padding: 1em; color: #222; }
+nav button { font-size: 1em; margin-right: .5em; }
+nav button.active { font-weight: bold; }
+.months { display: flex; flex-wrap: wrap; gap: 2em; }
+table.calendar { border-collapse: collapse; }
+.calendar th, .calendar td { width: 3em; height: 2.6em; text-align: center; vertical-align: top; font-size: .9em; }
+.calendar td { border: 1px solid #ddd; }
+.calendar td.has-todos { background: linear-gradient(to top, #9be9a8 var(--done), #fff var(--done)); }
+.calendar a { display: block; color: inherit; text-decoration: none; }
+.calendar small { display: block; color: #555; }
+section.day h2 { border-bottom: 1px solid #ccc; }
+section.day h2 small { float: right; font-weight: normal; color: #555; }
+ul.todos { list-style: none; padding-left: 0; }
+ul.todos li.done { color: #777; text-decoration: line-through; }
+body.only-open li.done, body.only-done li.open { display: none; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<nav>
+<button data-filter="all" class="active">All</button><button data-filter="open">Open</button><button data-filter="done">Done</button>
+<a href="#">Show all days</a>
+</nav>
+<div class="months">
+{{- range .Months}}
+<table class="calendar">
+<caption>{{.Title}}</caption>
+<tr><th>Mo</th><th>Tu</th><th>We</th><th>Th</th><th>Fr</th><th>Sa</th><th>Su</th></tr>
+{{- range .Weeks}}
+<tr>
+{{- range .}}
+{{- if .ID}}<td class="has-todos" style="--done: {{.Percent}}%"><a href="#{{.ID}}">{{.Day}}<small>{{.Done}}/{{.Total}}</small></a></td>
+{{- else if .Day}}<td>{{.Day}}</td>
+{{- else}}<td></td>
+{{- end}}
+{{- end}}
+</tr>
+{{- end}}
+</table>
+{{- end}}
+</div>
+{{- range .Days}}
+<section class="day" id="{{.ID}}">
+<h2>{{.Title}} <small>{{.Done}}/{{len .Todos}} done</small></h2>
+<ul class="todos">
+{{- range .Todos}}
+<li class="{{if .Complete}}done{{else}}open{{end}}"><input type="checkbox" disabled{{if .Complete}} checked{{end}}> {{if .Priority}}({{.Priority}}) {{end}}{{.Description}}</li>
+{{- end}}
+</ul>
+</section>
+{{- end}}
+<script>
+(function () {
+  var buttons = document.querySelectorAll("nav button");
+  buttons.forEach(function (button) {
+    button.addEventListener("click", function () {
+      buttons.forEach(function (b) { b.classList.toggle("active", b === button); });
+      document.body.classList.toggle("only-open", button.dataset.filter === "open");
+      document.body.classList.toggle("only-done", button.dataset.filter === "done");
+    });
+  });
+  function showDay() {
+    var id = location.hash.slice(1);
+    document.querySelectorAll("section.day").forEach(function (section) {
+      section.hidden = id !== "" && section.id !== id;
+    });
+  }
+  window.addEventListener("hashchange", showDay);
+  showDay();
+})();
+</script>
+</body>
+</html>
+`))
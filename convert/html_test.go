@@ -0,0 +1,73 @@
+package convert
+
+import (
+	"bytes"
+	"github.com/FChris/towg/task"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTML_Encode(t *testing.T) {
+	format, err := ByName("html")
+	assert.Equal(t, nil, err, "Error for looking up format is not nil")
+
+	list := testDayList()
+	list[1].Todos = append(list[1].Todos, task.Todo{Description: "<script>alert(1)</script>", Complete: true})
+
+	var buf bytes.Buffer
+	err = format.Encode(&buf, list, Options{})
+	assert.Equal(t, nil, err, "Error for encoding is not nil")
+	html := buf.String()
+
+	assert.Contains(t, html, `<caption>July 2017</caption>`, "Calendar of the month is missing")
+	assert.Contains(
+		t,
+		html,
+		`<td class="has-todos" style="--done: 50%"><a href="#day-2017-07-17">17<small>1/2</small></a></td>`,
+		"Calendar does not link to the day with its completion")
+	assert.Contains(t, html, `<section class="day" id="day-2017-07-16">`, "Section of the day is missing")
+	assert.Contains(
+		t,
+		html,
+		`<li class="open"><input type="checkbox" disabled> (A) Call Mom &#43;family @phone</li>`,
+		"Todo is missing")
+	assert.Contains(t, html, "&lt;script&gt;alert(1)&lt;/script&gt;", "Description was not escaped")
+	assert.True(
+		t,
+		strings.Index(html, "day-2017-07-16\">") < strings.Index(html, "day-2017-07-17\">"),
+		"Days are not in chronological order")
+}
+
+func TestHTML_EncodeDayOrders(t *testing.T) {
+	format, err := ByName("html")
+	assert.Equal(t, nil, err, "Error for looking up format is not nil")
+
+	for _, order := range []task.DayOrder{task.NewestFirst, task.OldestFirst} {
+		list := testDayList()
+		list = append(list, task.Day{Date: list[0].Date.AddDate(0, 1, 0), Todos: task.TodoList{{Description: "Later"}}})
+		order.Sort(list)
+
+		var buf bytes.Buffer
+		err = format.Encode(&buf, list, Options{})
+		assert.Equal(t, nil, err, "Error for encoding is not nil")
+		html := buf.String()
+
+		assert.Contains(t, html, "<title>towg 16.07.17 - 17.08.17</title>", "Title does not span all days")
+		assert.Contains(t, html, "<caption>July 2017</caption>", "Calendar of the first month is missing")
+		assert.Contains(t, html, "<caption>August 2017</caption>", "Calendar of the last month is missing")
+		assert.True(
+			t,
+			strings.Index(html, "day-2017-07-16\">") < strings.Index(html, "day-2017-07-17\">") &&
+				strings.Index(html, "day-2017-07-17\">") < strings.Index(html, "day-2017-08-17\">"),
+			"Days are not in chronological order")
+	}
+}
+
+func TestHTML_CalendarMonth(t *testing.T) {
+	month := calendarMonth(time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC), nil)
+	assert.Equal(t, 6, len(month.Weeks), "July 2017 does not span six weeks")
+	assert.Equal(t, 1, month.Weeks[0][5].Day, "July 2017 does not start on a Saturday")
+	assert.Equal(t, 31, month.Weeks[5][0].Day, "July 2017 does not end on a Monday")
+}
@@ -0,0 +1,291 @@
+package convert
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"fmt"
+	"github.com/FChris/towg/task"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// init registers the ics format, which reads and writes RFC 5545 calendars of VTODO components.
+// The day of a todo is written as DUE date. When reading, DUE is used if present and DTSTART otherwise, todos
+// without both are put on the current day. The description is the SUMMARY, completion is STATUS:COMPLETED and
+// the priorities A to I map onto the PRIORITY values 1 to 9. The projects and contexts of the description are
+// written as CATEGORIES, categories that are not part of the summary are read as projects.
+func init() {
+	register(Format{Name: "ics", Encode: encodeICal, Decode: decodeICal})
+}
+
+const (
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405Z"
+
+	// icalLineLength is the maximum length of a line in octets before it has to be folded
+	icalLineLength = 75
+
+	// icalTowgPriority holds the letter of the priorities J to Z, which iCalendar has no priority for
+	icalTowgPriority = "X-TOWG-PRIORITY"
+)
+
+func encodeICal(w io.Writer, list task.DayList, _ Options) error {
+	out := &icalWriter{w: bufio.NewWriter(w)}
+	stamp := now().UTC().Format(icalDateTime)
+
+	out.line("BEGIN:VCALENDAR")
+	out.line("VERSION:2.0")
+	out.line("PRODID:-//FChris//towg//EN")
+	for _, day := range list {
+		for _, todo := range day.Todos {
+			out.line("BEGIN:VTODO")
+			out.line("UID:" + icalUID(day.Date, todo))
+			out.line("DTSTAMP:" + stamp)
+			if !todo.Created.IsZero() {
+				out.line("CREATED:" + todo.Created.Format(icalDateTime))
+			}
+			out.line("DUE;VALUE=DATE:" + day.Date.Format(icalDate))
+			out.line("SUMMARY:" + icalEscape(todo.Description))
+			if todo.Complete {
+				out.line("STATUS:COMPLETED")
+			} else {
+				out.line("STATUS:NEEDS-ACTION")
+			}
+			if !todo.Completed.IsZero() {
+				out.line("COMPLETED:" + todo.Completed.Format(icalDateTime))
+			}
+			if todo.Priority != "" {
+				out.line("PRIORITY:" + strconv.Itoa(icalPriority(todo.Priority)))
+				if todo.Priority > "I" {
+					out.line(icalTowgPriority + ":" + todo.Priority)
+				}
+			}
+			if categories := append(todo.Projects(), todo.Contexts()...); len(categories) > 0 {
+				for i, category := range categories {
+					categories[i] = icalEscape(category)
+				}
+				out.line("CATEGORIES:" + strings.Join(categories, ","))
+			}
+			out.line("END:VTODO")
+		}
+	}
+	out.line("END:VCALENDAR")
+
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// icalUID returns an identifier for the todo which stays the same as long as its day and text do
+func icalUID(date time.Time, todo task.Todo) string {
+	sum := sha1.Sum([]byte(date.Format(task.DateFormat) + "\n" + todo.Text()))
+	return fmt.Sprintf("%x@towg", sum[:10])
+}
+
+// icalPriority maps the priorities A to I onto 1 to 9. Lower priorities are written as 9 and additionally as
+// X-TOWG-PRIORITY, so that they are read back as they were.
+func icalPriority(priority string) int {
+	p := int(priority[0]-'A') + 1
+	if p > 9 {
+		p = 9
+	}
+	return p
+}
+
+// icalWriter writes folded content lines terminated by CRLF
+type icalWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (i *icalWriter) line(s string) {
+	for i.err == nil && len(s) > icalLineLength {
+		n := icalLineLength
+		for !utf8.RuneStart(s[n]) {
+			n--
+		}
+		_, i.err = i.w.WriteString(s[:n] + "\r\n")
+		// Continuation lines start with a space, which counts towards their length
+		s = " " + s[n:]
+	}
+	if i.err == nil {
+		_, i.err = i.w.WriteString(s + "\r\n")
+	}
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+func icalEscape(s string) string {
+	return icalEscaper.Replace(s)
+}
+
+func icalUnescape(s string) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' || s[i] == 'N' {
+				buf.WriteByte('\n')
+			} else {
+				buf.WriteByte(s[i])
+			}
+			continue
+		}
+		buf.WriteByte(s[i])
+	}
+	return buf.String()
+}
+
+// icalSplitList splits a list value at all commas which are not escaped
+func icalSplitList(s string) []string {
+	var values []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+		} else if s[i] == ',' {
+			values = append(values, icalUnescape(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, icalUnescape(s[start:]))
+}
+
+func decodeICal(r io.Reader) (task.DayList, error) {
+	lines, err := icalUnfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	store := task.NewStore(nil)
+	var props map[string]string
+	for _, line := range lines {
+		name, value, ok := icalProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VTODO"):
+			props = make(map[string]string)
+		case name == "END" && strings.EqualFold(value, "VTODO") && props != nil:
+			date, todo, err := icalTodo(props)
+			if err != nil {
+				return nil, err
+			}
+			store.InsertTodo(date, todo)
+			props = nil
+		case props != nil:
+			if _, ok := props[name]; !ok {
+				props[name] = value
+			}
+		}
+	}
+	return store.DayList(), nil
+}
+
+// icalUnfold reads all content lines from r and joins folded lines
+func icalUnfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+		} else if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// icalProperty splits a content line like "DUE;VALUE=DATE:20170717" into its upper case name and its value.
+// Parameters are dropped.
+func icalProperty(line string) (name, value string, ok bool) {
+	colon := -1
+	quoted := false
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", "", false
+	}
+
+	name = line[:colon]
+	if i := strings.IndexByte(name, ';'); i >= 0 {
+		name = name[:i]
+	}
+	return strings.ToUpper(name), line[colon+1:], true
+}
+
+// icalTodo returns the todo and its day for the properties of a VTODO
+func icalTodo(props map[string]string) (date time.Time, todo task.Todo, err error) {
+	summary := icalUnescape(props["SUMMARY"])
+	if strings.TrimSpace(summary) == "" {
+		summary = icalUnescape(props["DESCRIPTION"])
+	}
+	todo.Description = strings.TrimSpace(summary)
+	if todo.Description == "" {
+		return date, todo, fmt.Errorf("VTODO %s has no summary", props["UID"])
+	}
+
+	todo.Complete = strings.EqualFold(props["STATUS"], "COMPLETED") || props["COMPLETED"] != ""
+	if todo.Created, err = icalParseDate(props["CREATED"]); err != nil {
+		return
+	}
+	if todo.Completed, err = icalParseDate(props["COMPLETED"]); err != nil {
+		return
+	}
+	if p, e := strconv.Atoi(props["PRIORITY"]); e == nil && p >= 1 && p <= 9 {
+		todo.Priority = string(rune('A' + p - 1))
+		// The letter is only used while the priority was not changed by another app
+		if letter := props[icalTowgPriority]; len(letter) == 1 && letter >= "A" && letter <= "Z" &&
+			icalPriority(letter) == p {
+			todo.Priority = letter
+		}
+	}
+
+	if categories, ok := props["CATEGORIES"]; ok {
+		words := " " + todo.Description + " "
+		for _, category := range icalSplitList(categories) {
+			category = strings.Join(strings.Fields(category), "-")
+			if category == "" || strings.Contains(words, " +"+category+" ") || strings.Contains(words, " @"+category+" ") {
+				continue
+			}
+			todo.Description += " +" + category
+		}
+	}
+
+	for _, name := range []string{"DUE", "DTSTART"} {
+		if date, err = icalParseDate(props[name]); err != nil || !date.IsZero() {
+			return
+		}
+	}
+	return today(), todo, nil
+}
+
+// icalParseDate returns the date of a DATE or DATE-TIME value. The time and time zone are dropped, since towg
+// only knows days.
+func icalParseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if len(value) < len(icalDate) {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	date, err := time.Parse(icalDate, value[:len(icalDate)])
+	if err != nil {
+		return date, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
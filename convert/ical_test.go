@@ -0,0 +1,97 @@
+package convert
+
+import (
+	"bytes"
+	"github.com/FChris/towg/task"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestICal_RoundTrip(t *testing.T) {
+	now = fixedNow
+	defer func() { now = time.Now }()
+
+	format, err := ByName("ics")
+	assert.Equal(t, nil, err, "Error for looking up format is not nil")
+
+	list := testDayList()
+	list[1].Todos.InsertTodo(task.Todo{
+		Description: "A rather long description; with special characters, like ä, ö and ü\nand a second line",
+		Complete:    true,
+	})
+
+	var buf bytes.Buffer
+	err = format.Encode(&buf, list, Options{})
+	assert.Equal(t, nil, err, "Error for encoding is not nil")
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		assert.True(t, len(line) <= icalLineLength, "Line %q is not folded", line)
+	}
+	assert.Contains(t, buf.String(), "CATEGORIES:family,phone\r\n", "Categories are missing")
+	assert.Contains(t, buf.String(), "PRIORITY:1\r\n", "Priority is missing")
+
+	decoded, err := format.Decode(&buf)
+	assert.Equal(t, nil, err, "Error for decoding is not nil")
+	assert.Equal(t, list, decoded, "DayList changed after encoding and decoding it")
+}
+
+func TestICal_Decode(t *testing.T) {
+	now = fixedNow
+	defer func() { now = time.Now }()
+
+	format, err := ByName("ics")
+	assert.Equal(t, nil, err, "Error for looking up format is not nil")
+
+	list, err := format.Decode(strings.NewReader("BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nSUMMARY:Not a todo\r\nEND:VEVENT\r\n" +
+		"BEGIN:VTODO\r\nDTSTART;TZID=Europe/Berlin:20170717T090000\r\n" +
+		"SUMMARY:Call \r\n Mom\r\nPRIORITY:2\r\nCATEGORIES:Family,Calls\\, urgent\r\n" +
+		"COMPLETED:20170718T101500Z\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nSUMMARY:Undated\r\nEND:VTODO\r\n" +
+		"END:VCALENDAR\r\n"))
+	assert.Equal(t, nil, err, "Error for decoding is not nil")
+
+	assert.Equal(
+		t,
+		task.DayList{
+			{Date: time.Date(2017, 7, 20, 0, 0, 0, 0, time.UTC), Todos: task.TodoList{{Description: "Undated"}}},
+			{Date: time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC), Todos: task.TodoList{{
+				Description: "Call Mom +Family +Calls,-urgent",
+				Complete:    true,
+				Priority:    "B",
+				Completed:   time.Date(2017, 7, 18, 0, 0, 0, 0, time.UTC),
+			}}},
+		},
+		list,
+		"Decoded list does not equal the expected list")
+}
+
+func TestICal_LowPriorities(t *testing.T) {
+	now = fixedNow
+	defer func() { now = time.Now }()
+
+	format, err := ByName("ics")
+	assert.Equal(t, nil, err, "Error for looking up format is not nil")
+
+	date := time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC)
+	list := task.DayList{{Date: date, Todos: task.TodoList{{Description: "Todo I", Priority: "I"},
+		{Description: "Todo J", Priority: "J"}, {Description: "Todo Z", Priority: "Z"}}}}
+
+	var buf bytes.Buffer
+	err = format.Encode(&buf, list, Options{})
+	assert.Equal(t, nil, err, "Error for encoding is not nil")
+	assert.Equal(t, 3, strings.Count(buf.String(), "PRIORITY:9\r\n"), "Low priorities are not written as 9")
+	assert.Equal(t, 1, strings.Count(buf.String(), "X-TOWG-PRIORITY:J\r\n"), "Priority J is not kept")
+	assert.Equal(t, 1, strings.Count(buf.String(), "X-TOWG-PRIORITY:Z\r\n"), "Priority Z is not kept")
+	assert.NotContains(t, buf.String(), "X-TOWG-PRIORITY:I", "Priority which iCalendar has is kept twice")
+
+	decoded, err := format.Decode(&buf)
+	assert.Equal(t, nil, err, "Error for decoding is not nil")
+	assert.Equal(t, list, decoded, "Low priorities changed after encoding and decoding them")
+
+	decoded, err = format.Decode(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nDUE;VALUE=DATE:20170717\r\n" +
+		"SUMMARY:Todo J\r\nPRIORITY:1\r\nX-TOWG-PRIORITY:J\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"))
+	assert.Equal(t, nil, err, "Error for decoding is not nil")
+	assert.Equal(t, "A", decoded[0].Todos[0].Priority, "Priority changed by another app is not read")
+}
@@ -0,0 +1,341 @@
+package convert
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"github.com/FChris/towg/parse"
+	"github.com/FChris/towg/task"
+	"io"
+	"strings"
+	"time"
+)
+
+// init registers the pdf format. It renders the days in chronological order with a heading per day and a drawn
+// checkbox per todo. Completed todos are ticked and greyed out. Only the standard Helvetica fonts are used, so
+// the file does not embed any font and characters outside of Windows-1252 are replaced by '?'.
+func init() {
+	register(Format{Name: "pdf", Encode: encodePDF})
+}
+
+// pdfLayout describes the paper size and font sizes of a pdf. All sizes are in points.
+type pdfLayout struct {
+	width, height float64
+	margin        float64
+	fontSize      float64
+	headingSize   float64
+}
+
+var (
+	// a4Layout is used for printing on A4 paper
+	a4Layout = pdfLayout{width: 595.28, height: 841.89, margin: 56, fontSize: 11, headingSize: 15}
+
+	// pocketLayout fits an A6 page, which is an A4 page folded twice
+	pocketLayout = pdfLayout{width: 297.64, height: 419.53, margin: 20, fontSize: 8, headingSize: 10}
+)
+
+const (
+	pdfRegular = "F1"
+	pdfBold    = "F2"
+)
+
+// pdfDocument collects the content streams of the pages of a pdf
+type pdfDocument struct {
+	layout pdfLayout
+	pages  []*bytes.Buffer
+	page   *bytes.Buffer
+	y      float64
+}
+
+func encodePDF(w io.Writer, list task.DayList, opts Options) error {
+	if opts.PageBreak != "" && opts.PageBreak != "day" && opts.PageBreak != "week" {
+		return fmt.Errorf("unknown page break %q, expected day or week", opts.PageBreak)
+	}
+
+	doc := &pdfDocument{layout: a4Layout}
+	if opts.Pocket {
+		doc.layout = pocketLayout
+	}
+	doc.newPage()
+
+	var last time.Time
+	for _, day := range chronological(list) {
+		if !last.IsZero() && startsPage(opts.PageBreak, last, day.Date) {
+			doc.newPage()
+		}
+		doc.day(day)
+		last = day.Date
+	}
+	if len(list) == 0 {
+		doc.line(pdfRegular, doc.layout.fontSize, doc.layout.margin, "No todos")
+	}
+
+	return doc.writeTo(w)
+}
+
+// startsPage returns true if the day with the given date has to start a new page after the day at last
+func startsPage(pageBreak string, last, date time.Time) bool {
+	switch pageBreak {
+	case "day":
+		return true
+	case "week":
+		lastYear, lastWeek := last.ISOWeek()
+		year, week := date.ISOWeek()
+		return lastYear != year || lastWeek != week
+	}
+	return false
+}
+
+func (d *pdfDocument) newPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = d.layout.height - d.layout.margin
+
+	footerSize := d.layout.fontSize * 0.8
+	fmt.Fprintf(d.page, "0.5 g\n")
+	d.text(pdfRegular, footerSize, d.layout.margin, d.layout.margin/2, fmt.Sprintf("towg - page %d", len(d.pages)))
+	fmt.Fprintf(d.page, "0 g\n")
+}
+
+// reserve starts a new page if less than height points are left on the current one
+func (d *pdfDocument) reserve(height float64) {
+	if d.y-height < d.layout.margin && d.y < d.layout.height-d.layout.margin {
+		d.newPage()
+	}
+}
+
+func (d *pdfDocument) day(day task.Day) {
+	l := d.layout
+	leading := l.fontSize * 1.5
+
+	// Keep the heading on the same page as the first todo
+	d.reserve(l.headingSize*2 + leading)
+	if d.y < l.height-l.margin {
+		d.y -= l.headingSize
+	}
+
+	done := 0
+	for _, todo := range day.Todos {
+		if todo.Complete {
+			done++
+		}
+	}
+	heading := day.Date.Format("Monday, " + parse.Timeformat)
+	d.line(pdfBold, l.headingSize, l.margin, heading)
+	summary := fmt.Sprintf("%d/%d done", done, len(day.Todos))
+	d.text(pdfRegular, l.fontSize, l.width-l.margin-textWidth(summary, l.fontSize), d.y, summary)
+	d.y -= l.headingSize * 0.4
+	fmt.Fprintf(d.page, "0.5 w %.2f %.2f m %.2f %.2f l S\n", l.margin, d.y, l.width-l.margin, d.y)
+	d.y -= l.headingSize * 0.6
+
+	box := l.fontSize * 0.8
+	textX := l.margin + box*2
+	for _, todo := range day.Todos {
+		desc := todo.Description
+		if todo.Priority != "" {
+			desc = "(" + todo.Priority + ") " + desc
+		}
+		lines := wrapText(desc, l.width-l.margin-textX, l.fontSize)
+
+		d.reserve(leading * float64(len(lines)))
+		for i, text := range lines {
+			d.reserve(leading)
+			d.y -= l.fontSize
+			if i == 0 {
+				d.checkbox(l.margin, d.y-box*0.1, box, todo.Complete)
+			}
+			if todo.Complete {
+				fmt.Fprintf(d.page, "0.45 g\n")
+			}
+			d.text(pdfRegular, l.fontSize, textX, d.y, text)
+			fmt.Fprintf(d.page, "0 g\n")
+			d.y -= leading - l.fontSize
+		}
+	}
+	d.y -= leading
+}
+
+// line writes a single line of text at the current position and moves below it
+func (d *pdfDocument) line(font string, size, x float64, s string) {
+	d.y -= size
+	d.text(font, size, x, d.y, s)
+}
+
+func (d *pdfDocument) text(font string, size, x, y float64, s string) {
+	fmt.Fprintf(d.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+// checkbox draws a square with its lower left corner at x, y and ticks it if checked is true
+func (d *pdfDocument) checkbox(x, y, size float64, checked bool) {
+	fmt.Fprintf(d.page, "0.8 w %.2f %.2f %.2f %.2f re S\n", x, y, size, size)
+	if checked {
+		fmt.Fprintf(d.page, "1.2 w %.2f %.2f m %.2f %.2f l %.2f %.2f l S\n",
+			x+size*0.2, y+size*0.5, x+size*0.45, y+size*0.2, x+size*0.85, y+size*0.85)
+	}
+}
+
+// writeTo writes the document with all its objects and the cross reference table to w
+func (d *pdfDocument) writeTo(w io.Writer) error {
+	out := &pdfWriter{w: bufio.NewWriter(w)}
+	out.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// Object 1 is the catalog, 2 the page tree and 3 and 4 the fonts.
+	// Every page is followed by its content stream.
+	const firstPage = 5
+	var kids []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+
+	out.object("<< /Type /Catalog /Pages 2 0 R >>")
+	out.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	out.object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	out.object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range d.pages {
+		out.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			d.layout.width, d.layout.height, pdfRegular, pdfBold, firstPage+2*i+1))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		zw.Write(page.Bytes())
+		zw.Close()
+		out.object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream",
+			compressed.Len(), compressed.Bytes()))
+	}
+
+	xref := out.n
+	out.printf("xref\n0 %d\n0000000000 65535 f \n", len(out.offsets)+1)
+	for _, offset := range out.offsets {
+		out.printf("%010d 00000 n \n", offset)
+	}
+	out.printf("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(out.offsets)+1, xref)
+
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// pdfWriter keeps track of the byte offsets of the objects it writes
+type pdfWriter struct {
+	w       *bufio.Writer
+	n       int
+	offsets []int
+	err     error
+}
+
+func (p *pdfWriter) printf(format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	var n int
+	n, p.err = fmt.Fprintf(p.w, format, args...)
+	p.n += n
+}
+
+func (p *pdfWriter) object(content string) {
+	p.offsets = append(p.offsets, p.n)
+	p.printf("%d 0 obj\n%s\nendobj\n", len(p.offsets), content)
+}
+
+// pdfString converts s to Windows-1252 and escapes it for use in a pdf string literal
+func pdfString(s string) string {
+	var buf bytes.Buffer
+	for _, r := range s {
+		c := winAnsi(r)
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c < 32 || c > 126:
+			fmt.Fprintf(&buf, "\\%03o", c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+// winAnsiSpecials are the characters of Windows-1252 which differ from ISO-8859-1
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95,
+	'–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+func winAnsi(r rune) byte {
+	switch {
+	case r == '\t' || r == '\n':
+		return ' '
+	case r < 128 || (r >= 0xa0 && r <= 0xff):
+		return byte(r)
+	}
+	if c, ok := winAnsiSpecials[r]; ok {
+		return c
+	}
+	return '?'
+}
+
+// helveticaWidths are the widths of the printable ASCII characters of Helvetica in thousandths of the font size
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0 to 9
+	278, 278, 584, 584, 584, 556, 1015, // : to @
+	667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // A to M
+	722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // N to Z
+	278, 278, 278, 469, 556, 333, // [ to `
+	556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // a to m
+	556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // n to z
+	334, 260, 334, 584, // { to ~
+}
+
+// textWidth returns the width of s in points when it is written in Helvetica of the given size
+func textWidth(s string, size float64) float64 {
+	width := 0
+	for _, r := range s {
+		c := winAnsi(r)
+		if c >= 32 && c <= 126 {
+			width += helveticaWidths[c-32]
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) * size / 1000
+}
+
+// wrapText splits s into lines which are at most maxWidth points wide. Words which are too long for a line on
+// their own are split.
+func wrapText(s string, maxWidth, size float64) []string {
+	var lines []string
+	var current string
+	for _, word := range strings.Fields(s) {
+		for textWidth(word, size) > maxWidth {
+			runes := []rune(word)
+			i := 1
+			for i < len(runes) && textWidth(string(runes[:i+1]), size) <= maxWidth {
+				i++
+			}
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			lines = append(lines, string(runes[:i]))
+			word = string(runes[i:])
+		}
+
+		if current == "" {
+			current = word
+		} else if textWidth(current+" "+word, size) <= maxWidth {
+			current += " " + word
+		} else {
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" || len(lines) == 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
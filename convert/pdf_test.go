@@ -0,0 +1,101 @@
+package convert
+
+import (
+	"bytes"
+	"compress/zlib"
+	"github.com/FChris/towg/task"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func encodeTestPDF(t *testing.T, list task.DayList, opts Options) string {
+	format, err := ByName("pdf")
+	assert.Equal(t, nil, err, "Error for looking up format is not nil")
+
+	var buf bytes.Buffer
+	err = format.Encode(&buf, list, opts)
+	assert.Equal(t, nil, err, "Error for encoding is not nil")
+	return buf.String()
+}
+
+// pdfContent returns the uncompressed content streams of the pages of the pdf
+func pdfContent(t *testing.T, pdf string) string {
+	var content strings.Builder
+	for _, match := range regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllStringSubmatch(pdf, -1) {
+		r, err := zlib.NewReader(strings.NewReader(match[1]))
+		if err != nil {
+			continue
+		}
+		data, err := ioutil.ReadAll(r)
+		assert.Equal(t, nil, err, "Error for uncompressing content stream is not nil")
+		content.Write(data)
+	}
+	return content.String()
+}
+
+func TestPDF_CrossReferences(t *testing.T) {
+	pdf := encodeTestPDF(t, testDayList(), Options{})
+	assert.True(t, strings.HasPrefix(pdf, "%PDF-1.4\n"), "PDF does not start with a header")
+	assert.True(t, strings.HasSuffix(pdf, "%%EOF\n"), "PDF does not end with an end of file marker")
+
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(pdf)
+	assert.Equal(t, 2, len(match), "PDF has no startxref")
+	xref, _ := strconv.Atoi(match[1])
+	assert.True(t, strings.HasPrefix(pdf[xref:], "xref\n"), "startxref does not point to the xref table")
+
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(pdf, -1)
+	assert.Equal(t, 6, len(offsets), "PDF does not contain a single page")
+	for i, offset := range offsets {
+		n, _ := strconv.Atoi(offset[1])
+		assert.True(
+			t,
+			strings.HasPrefix(pdf[n:], strconv.Itoa(i+1)+" 0 obj\n"),
+			"xref entry %d does not point to its object", i+1)
+	}
+}
+
+func TestPDF_PageBreaks(t *testing.T) {
+	monday := time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC)
+	list := task.DayList{
+		{Date: monday.AddDate(0, 0, 1), Todos: task.TodoList{{Description: "Todo 2"}}},
+		{Date: monday, Todos: task.TodoList{{Description: "Todo 1"}}},
+		{Date: monday.AddDate(0, 0, -1), Todos: task.TodoList{{Description: "Todo 0"}}},
+	}
+
+	for _, order := range []task.DayOrder{task.NewestFirst, task.OldestFirst} {
+		order.Sort(list)
+		for pageBreak, pages := range map[string]string{"": "1", "week": "2", "day": "3"} {
+			pdf := encodeTestPDF(t, list, Options{PageBreak: pageBreak})
+			assert.Contains(t, pdf, "/Count "+pages+" >>", "Wrong number of pages for page break %q", pageBreak)
+		}
+
+		content := pdfContent(t, encodeTestPDF(t, list, Options{}))
+		assert.True(
+			t,
+			strings.Index(content, "(Todo 0)") < strings.Index(content, "(Todo 1)") &&
+				strings.Index(content, "(Todo 1)") < strings.Index(content, "(Todo 2)"),
+			"Days are not in chronological order")
+	}
+
+	pdf := encodeTestPDF(t, list, Options{Pocket: true})
+	assert.Contains(t, pdf, "/MediaBox [0 0 297.64 419.53]", "Pocket layout does not use A6 pages")
+}
+
+func TestPDF_WrapText(t *testing.T) {
+	assert.Equal(
+		t,
+		[]string{"Call Mom", "about the", "meatballs"},
+		wrapText("Call Mom about the meatballs", textWidth("meatballs", 10), 10),
+		"Text was not wrapped at word boundaries")
+	assert.Equal(
+		t,
+		[]string{"Schön", "heit"},
+		wrapText("Schönheit", textWidth("Schön", 10), 10),
+		"Long words were not split")
+	assert.Equal(t, `Caf\351 \(1\)`, pdfString("Café (1)"), "String was not escaped")
+}
@@ -0,0 +1,266 @@
+package convert
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/FChris/towg/task"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The json and yaml formats share the following schema. A file is a list of days in the order of the todo file,
+// which is newest first unless another day order is configured:
+//
+//	- date: "2017-07-17"            the day as yyyy-mm-dd
+//	  todos:
+//	  - description: "Call Mom +family @phone"
+//	    complete: true
+//	    priority: "A"               optional, a letter from A to Z
+//	    created: "2017-07-01"       optional, yyyy-mm-dd
+//	    completed: "2017-07-17"     optional, yyyy-mm-dd
+//	    projects: ["family"]        derived from the description, ignored on import
+//	    contexts: ["phone"]         derived from the description, ignored on import
+//	    tags: {}                    key:value pairs of the description, ignored on import
+//
+// The csv format has one row per todo with a header row naming the columns
+//
+//	date,description,complete,priority,created,completed,projects,contexts
+//
+// where complete is true or false and projects and contexts are separated by spaces. On import the columns are
+// matched by the header, so their order does not matter and only date and description are required.
+func init() {
+	register(Format{Name: "json", Encode: encodeJSON, Decode: decodeJSON})
+	register(Format{Name: "yaml", Encode: encodeYAML, Decode: decodeYAML})
+	register(Format{Name: "csv", Encode: encodeCSV, Decode: decodeCSV})
+}
+
+// DayRecord is a day in the json and yaml schema
+type DayRecord struct {
+	Date  string       `json:"date" yaml:"date"`
+	Todos []TodoRecord `json:"todos" yaml:"todos"`
+}
+
+// TodoRecord is a todo in the json and yaml schema
+type TodoRecord struct {
+	Description string            `json:"description" yaml:"description"`
+	Complete    bool              `json:"complete" yaml:"complete"`
+	Priority    string            `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Created     string            `json:"created,omitempty" yaml:"created,omitempty"`
+	Completed   string            `json:"completed,omitempty" yaml:"completed,omitempty"`
+	Projects    []string          `json:"projects,omitempty" yaml:"projects,omitempty"`
+	Contexts    []string          `json:"contexts,omitempty" yaml:"contexts,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+var csvHeader = []string{"date", "description", "complete", "priority", "created", "completed", "projects", "contexts"}
+
+// ToRecords converts the list to the json and yaml schema
+func ToRecords(list task.DayList) []DayRecord {
+	records := make([]DayRecord, 0, len(list))
+	for _, day := range list {
+		record := DayRecord{Date: day.Date.Format(task.DateFormat), Todos: make([]TodoRecord, 0, len(day.Todos))}
+		for _, todo := range day.Todos {
+			record.Todos = append(record.Todos, ToRecord(todo))
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// ToRecord converts the todo to the json and yaml schema
+func ToRecord(todo task.Todo) TodoRecord {
+	r := TodoRecord{
+		Description: todo.Description,
+		Complete:    todo.Complete,
+		Priority:    todo.Priority,
+		Created:     formatDate(todo.Created),
+		Completed:   formatDate(todo.Completed),
+		Projects:    todo.Projects(),
+		Contexts:    todo.Contexts(),
+		Tags:        todo.Tags(),
+	}
+	if len(r.Tags) == 0 {
+		r.Tags = nil
+	}
+	return r
+}
+
+func fromRecords(records []DayRecord) (task.DayList, error) {
+	store := task.NewStore(nil)
+	for i, record := range records {
+		date, err := time.Parse(task.DateFormat, record.Date)
+		if err != nil {
+			return nil, fmt.Errorf("day %d: invalid date %q", i+1, record.Date)
+		}
+
+		store.Insert(date, nil)
+		for j, r := range record.Todos {
+			todo, err := fromRecord(r)
+			if err != nil {
+				return nil, fmt.Errorf("day %d, todo %d: %s", i+1, j+1, err)
+			}
+			store.InsertTodo(date, todo)
+		}
+	}
+	return store.DayList(), nil
+}
+
+func fromRecord(r TodoRecord) (todo task.Todo, err error) {
+	todo = task.Todo{Description: strings.TrimSpace(r.Description), Complete: r.Complete, Priority: r.Priority}
+	if todo.Description == "" {
+		return todo, fmt.Errorf("missing description")
+	}
+	if r.Priority != "" && (len(r.Priority) != 1 || r.Priority[0] < 'A' || r.Priority[0] > 'Z') {
+		return todo, fmt.Errorf("invalid priority %q", r.Priority)
+	}
+	if todo.Created, err = parseOptionalDate(r.Created); err != nil {
+		return todo, err
+	}
+	todo.Completed, err = parseOptionalDate(r.Completed)
+	return todo, err
+}
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(task.DateFormat)
+}
+
+func parseOptionalDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(task.DateFormat, s)
+	if err != nil {
+		return date, fmt.Errorf("invalid date %q", s)
+	}
+	return date, nil
+}
+
+func encodeJSON(w io.Writer, list task.DayList, _ Options) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ToRecords(list))
+}
+
+func decodeJSON(r io.Reader) (task.DayList, error) {
+	var records []DayRecord
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, err
+	}
+	return fromRecords(records)
+}
+
+func encodeYAML(w io.Writer, list task.DayList, _ Options) error {
+	data, err := yaml.Marshal(ToRecords(list))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func decodeYAML(r io.Reader) (task.DayList, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var records []DayRecord
+	if err = yaml.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return fromRecords(records)
+}
+
+func encodeCSV(w io.Writer, list task.DayList, _ Options) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, day := range ToRecords(list) {
+		for _, todo := range day.Todos {
+			row := []string{
+				day.Date,
+				todo.Description,
+				strconv.FormatBool(todo.Complete),
+				todo.Priority,
+				todo.Created,
+				todo.Completed,
+				strings.Join(todo.Projects, " "),
+				strings.Join(todo.Contexts, " "),
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func decodeCSV(r io.Reader) (task.DayList, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return task.DayList{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"date", "description"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	store := task.NewStore(nil)
+	for rowNo := 2; ; rowNo++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		date, err := time.Parse(task.DateFormat, field("date"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid date %q", rowNo, field("date"))
+		}
+		complete := false
+		if s := field("complete"); s != "" {
+			if complete, err = strconv.ParseBool(s); err != nil {
+				return nil, fmt.Errorf("row %d: invalid value %q for complete", rowNo, s)
+			}
+		}
+		todo, err := fromRecord(TodoRecord{
+			Description: field("description"),
+			Complete:    complete,
+			Priority:    field("priority"),
+			Created:     field("created"),
+			Completed:   field("completed"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %s", rowNo, err)
+		}
+		store.InsertTodo(date, todo)
+	}
+	return store.DayList(), nil
+}
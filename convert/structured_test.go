@@ -0,0 +1,82 @@
+package convert
+
+import (
+	"bytes"
+	"github.com/FChris/towg/task"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testDayList() task.DayList {
+	date := time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC)
+	return task.DayList{
+		{Date: date, Todos: task.TodoList{
+			{Description: "Todo 25, with comma", Complete: true, Completed: date},
+			{Description: "Call Mom +family @phone", Priority: "A", Created: date.AddDate(0, 0, -3)},
+		}},
+		{Date: date.AddDate(0, 0, -1), Todos: task.TodoList{{Description: "Todo \"7\""}}},
+	}
+}
+
+func TestStructured_RoundTrip(t *testing.T) {
+	for _, name := range []string{"json", "yaml", "csv"} {
+		format, err := ByName(name)
+		assert.Equal(t, nil, err, "Error for looking up format is not nil")
+
+		var buf bytes.Buffer
+		err = format.Encode(&buf, testDayList(), Options{})
+		assert.Equal(t, nil, err, "Error for encoding %s is not nil", name)
+
+		list, err := format.Decode(&buf)
+		assert.Equal(t, nil, err, "Error for decoding %s is not nil", name)
+		assert.Equal(t, testDayList(), list, "DayList changed after encoding and decoding it as %s", name)
+	}
+}
+
+func TestJSON_Encode(t *testing.T) {
+	format, err := ByName("json")
+	assert.Equal(t, nil, err, "Error for looking up format is not nil")
+
+	var buf bytes.Buffer
+	err = format.Encode(&buf, testDayList()[:1], Options{})
+	assert.Equal(t, nil, err, "Error for encoding is not nil")
+	assert.Equal(t, `[
+  {
+    "date": "2017-07-17",
+    "todos": [
+      {
+        "description": "Todo 25, with comma",
+        "complete": true,
+        "completed": "2017-07-17"
+      },
+      {
+        "description": "Call Mom +family @phone",
+        "complete": false,
+        "priority": "A",
+        "created": "2017-07-14",
+        "projects": [
+          "family"
+        ],
+        "contexts": [
+          "phone"
+        ]
+      }
+    ]
+  }
+]
+`, buf.String(), "JSON does not match the documented schema")
+}
+
+func TestCSV_Decode(t *testing.T) {
+	format, err := ByName("csv")
+	assert.Equal(t, nil, err, "Error for looking up format is not nil")
+
+	list, err := format.Decode(strings.NewReader("Description,Date\n\"Todo \"\"7\"\"\",2017-07-16\n"))
+	assert.Equal(t, nil, err, "Error for decoding is not nil")
+	assert.Equal(t, testDayList()[1:], list, "Columns were not matched by the header")
+
+	_, err = format.Decode(strings.NewReader("date,description,complete\n2017-07-16,Todo 7,maybe\n"))
+	assert.EqualError(t, err, `row 2: invalid value "maybe" for complete`, "Invalid rows are not reported")
+}
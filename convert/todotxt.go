@@ -0,0 +1,185 @@
+package convert
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/FChris/towg/task"
+	"io"
+	"strings"
+	"time"
+)
+
+// init registers the todotxt format, which follows http://todotxt.org with one task per line:
+//
+//	x 2017-07-17 2017-07-01 Call Mom +family @phone pri:A
+//	(A) 2017-07-01 Call Mom +family @phone due:2017-07-17
+//
+// The completion mark, priority, completion and creation dates map onto the fields of task.Todo. Projects, contexts
+// and key:value pairs stay part of the description, where task.Todo.Projects, Contexts and Tags find them.
+// The due: pair is the day of the todo. Todos without it are put on their creation date, then on their
+// completion date and finally on the current day. When writing, due: is only left out if the todo would be read
+// into its day by its creation or completion date, so that a file read on another day keeps the days.
+// The priority of a completed task is written as pri: pair, since todo.txt drops the priority on completion.
+func init() {
+	register(Format{Name: "todotxt", Encode: encodeTodoTxt, Decode: decodeTodoTxt})
+}
+
+const (
+	dueKey      = "due"
+	priorityKey = "pri"
+)
+
+func decodeTodoTxt(r io.Reader) (task.DayList, error) {
+	store := task.NewStore(nil)
+	lines := bufio.NewScanner(r)
+	for lines.Scan() {
+		text := strings.TrimSpace(lines.Text())
+		if text == "" {
+			continue
+		}
+
+		store.InsertTodo(parseTodoTxt(text))
+	}
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf("reading todo.txt: %s", err)
+	}
+
+	return store.DayList(), nil
+}
+
+// parseTodoTxt reads a single line in todo.txt format and returns the todo and the day it belongs to
+func parseTodoTxt(text string) (date time.Time, todo task.Todo) {
+	words := strings.Fields(text)
+
+	if len(words) > 0 && words[0] == "x" {
+		todo.Complete = true
+		words = words[1:]
+		if d, ok := todoTxtDate(words); ok {
+			todo.Completed = d
+			words = words[1:]
+			if d, ok := todoTxtDate(words); ok {
+				todo.Created = d
+				words = words[1:]
+			}
+		}
+	}
+
+	if len(words) > 0 && isTodoTxtPriority(words[0]) {
+		todo.Priority = words[0][1:2]
+		words = words[1:]
+	}
+
+	if d, ok := todoTxtDate(words); ok && todo.Created.IsZero() {
+		todo.Created = d
+		words = words[1:]
+	}
+
+	var desc []string
+	for _, word := range words {
+		key, value := splitPair(word)
+		switch {
+		case key == dueKey && date.IsZero() && isDate(value):
+			date, _ = time.Parse(task.DateFormat, value)
+		case key == priorityKey && todo.Priority == "" && isTodoTxtPriority("("+value+")"):
+			todo.Priority = value
+		default:
+			desc = append(desc, word)
+		}
+	}
+	todo.Description = strings.Join(desc, " ")
+
+	if date.IsZero() {
+		var ok bool
+		if date, ok = impliedDate(todo); !ok {
+			date = today()
+		}
+	}
+	return date, todo
+}
+
+// impliedDate returns the day a todo without due: pair is put on by its creation or completion date. It returns
+// false if the todo has neither, in which case it is put on the current day.
+func impliedDate(todo task.Todo) (time.Time, bool) {
+	switch {
+	case !todo.Created.IsZero():
+		return todo.Created, true
+	case !todo.Completed.IsZero():
+		return todo.Completed, true
+	}
+	return time.Time{}, false
+}
+
+func encodeTodoTxt(w io.Writer, list task.DayList, _ Options) error {
+	bw := bufio.NewWriter(w)
+	for _, day := range list {
+		for _, todo := range day.Todos {
+			if _, err := bw.WriteString(formatTodoTxt(day.Date, todo) + "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// formatTodoTxt returns the todo.txt line for a todo on the given day
+func formatTodoTxt(date time.Time, todo task.Todo) string {
+	var words []string
+
+	if todo.Complete {
+		words = append(words, "x")
+		completed := todo.Completed
+		if completed.IsZero() && !todo.Created.IsZero() {
+			// todo.txt only allows a creation date after a completion date
+			completed = date
+		}
+		if !completed.IsZero() {
+			words = append(words, completed.Format(task.DateFormat))
+		}
+	} else if todo.Priority != "" {
+		words = append(words, "("+todo.Priority+")")
+	}
+
+	if !todo.Created.IsZero() {
+		words = append(words, todo.Created.Format(task.DateFormat))
+	}
+
+	if todo.Description != "" {
+		words = append(words, strings.Fields(todo.Description)...)
+	}
+
+	if todo.Complete && todo.Priority != "" {
+		words = append(words, priorityKey+":"+todo.Priority)
+	}
+
+	implied, ok := impliedDate(todo)
+	if !ok || implied.Year() != date.Year() || implied.YearDay() != date.YearDay() {
+		words = append(words, dueKey+":"+date.Format(task.DateFormat))
+	}
+
+	return strings.Join(words, " ")
+}
+
+func todoTxtDate(words []string) (time.Time, bool) {
+	if len(words) == 0 || !isDate(words[0]) {
+		return time.Time{}, false
+	}
+	d, _ := time.Parse(task.DateFormat, words[0])
+	return d, true
+}
+
+func isDate(word string) bool {
+	_, err := time.Parse(task.DateFormat, word)
+	return err == nil
+}
+
+func isTodoTxtPriority(word string) bool {
+	return len(word) == 3 && word[0] == '(' && word[1] >= 'A' && word[1] <= 'Z' && word[2] == ')'
+}
+
+func splitPair(word string) (key, value string) {
+	i := strings.IndexByte(word, ':')
+	if i <= 0 || i == len(word)-1 {
+		return "", ""
+	}
+	return word[:i], word[i+1:]
+}
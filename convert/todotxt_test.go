@@ -0,0 +1,119 @@
+package convert
+
+import (
+	"bytes"
+	"github.com/FChris/towg/task"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fixedNow() time.Time {
+	return time.Date(2017, 7, 20, 15, 4, 5, 0, time.Local)
+}
+
+func TestTodoTxt_RoundTrip(t *testing.T) {
+	now = fixedNow
+	defer func() { now = time.Now }()
+
+	lines := []string{
+		"(A) Thank Mom for the meatballs @phone due:2017-07-10",
+		"(B) 2017-07-01 Schedule Goodwill pickup +GarageSale @phone",
+		"x 2017-07-03 Call Mom",
+		"x 2017-07-02 2017-07-01 Review Tim's pull request +TodoTxtTouch @github pri:C",
+		"(A) 2017-07-01 Call Mom about the meatballs due:2017-07-05",
+		"x Post signs around the neighborhood +GarageSale id:42 due:2017-07-04",
+	}
+	format, err := ByName("todotxt")
+	assert.Equal(t, nil, err, "Error for looking up format is not nil")
+
+	list, err := format.Decode(strings.NewReader(strings.Join(lines, "\n")))
+	assert.Equal(t, nil, err, "Error for decoding is not nil")
+
+	var buf bytes.Buffer
+	err = format.Encode(&buf, list, Options{})
+	assert.Equal(t, nil, err, "Error for encoding is not nil")
+
+	sort.Strings(lines)
+	encoded := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	sort.Strings(encoded)
+	assert.Equal(t, lines, encoded, "Lines changed after decoding and encoding them")
+}
+
+func TestTodoTxt_Decode(t *testing.T) {
+	now = fixedNow
+	defer func() { now = time.Now }()
+
+	format, err := ByName("todotxt")
+	assert.Equal(t, nil, err, "Error for looking up format is not nil")
+
+	list, err := format.Decode(strings.NewReader(
+		"x 2017-07-02 2017-07-01 Review pull request +towg @github pri:C\n\nPost signs +GarageSale\n"))
+	assert.Equal(t, nil, err, "Error for decoding is not nil")
+
+	completed := time.Date(2017, 7, 2, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC)
+	expectedList := task.DayList{
+		{Date: time.Date(2017, 7, 20, 0, 0, 0, 0, time.UTC), Todos: task.TodoList{
+			{Description: "Post signs +GarageSale"},
+		}},
+		{Date: created, Todos: task.TodoList{{
+			Description: "Review pull request +towg @github",
+			Complete:    true,
+			Priority:    "C",
+			Created:     created,
+			Completed:   completed,
+		}}},
+	}
+	assert.Equal(t, expectedList, list, "Decoded list does not equal the expected list")
+	assert.Equal(t, []string{"towg"}, list[1].Todos[0].Projects(), "Projects of the todo are wrong")
+	assert.Equal(t, []string{"github"}, list[1].Todos[0].Contexts(), "Contexts of the todo are wrong")
+}
+
+func TestTodoTxt_RoundTripOtherDay(t *testing.T) {
+	now = fixedNow
+	defer func() { now = time.Now }()
+
+	format, err := ByName("todotxt")
+	assert.Equal(t, nil, err, "Error for looking up format is not nil")
+
+	list, err := format.Decode(strings.NewReader("Post signs +GarageSale\nx 2017-07-02 Call Mom\n"))
+	assert.Equal(t, nil, err, "Error for decoding is not nil")
+
+	var buf bytes.Buffer
+	err = format.Encode(&buf, list, Options{})
+	assert.Equal(t, nil, err, "Error for encoding is not nil")
+	assert.Contains(t, buf.String(), "Post signs +GarageSale due:2017-07-20\n", "Todo without dates has no due: pair")
+	assert.Contains(t, buf.String(), "x 2017-07-02 Call Mom\n", "Todo on its completion date has a due: pair")
+
+	now = func() time.Time { return fixedNow().AddDate(0, 0, 3) }
+	decoded, err := format.Decode(&buf)
+	assert.Equal(t, nil, err, "Error for decoding is not nil")
+	assert.Equal(t, list, decoded, "DayList changed after encoding it and decoding it on another day")
+}
+
+func TestTodoTxt_RoundTripDayList(t *testing.T) {
+	date := time.Date(2017, 7, 17, 0, 0, 0, 0, time.UTC)
+	list := task.DayList{{Date: date, Todos: task.TodoList{
+		{Description: "Todo 25", Complete: true},
+		{Description: "Todo 2 +towg key:value", Priority: "B", Created: date.AddDate(0, 0, -3)},
+	}}}
+
+	format, err := ByName("todotxt")
+	assert.Equal(t, nil, err, "Error for looking up format is not nil")
+
+	var buf bytes.Buffer
+	err = format.Encode(&buf, list, Options{})
+	assert.Equal(t, nil, err, "Error for encoding is not nil")
+	assert.Equal(
+		t,
+		"x Todo 25 due:2017-07-17\n(B) 2017-07-14 Todo 2 +towg key:value due:2017-07-17\n",
+		buf.String(),
+		"Encoded list does not equal the expected todo.txt lines")
+
+	decoded, err := format.Decode(&buf)
+	assert.Equal(t, nil, err, "Error for decoding is not nil")
+	assert.Equal(t, list, decoded, "DayList changed after encoding and decoding it")
+}
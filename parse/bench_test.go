@@ -0,0 +1,406 @@
+package parse
+
+// This file contains the rune based lexer and parser that were used before the parser became line oriented.
+// They are only kept to compare the performance of both implementations in the benchmarks below.
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/FChris/towg/task"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+//legacyToken identifies the type of data that was read
+type legacyToken int
+
+const (
+
+	//illegal represents anything that cannot be identified by any other token
+	illegal = iota
+
+	//eof represents the end of file token
+	eof
+
+	//ws identifies a whitespace
+	ws
+
+	//ident are identifiers of todos and dates
+	ident
+
+	//statusOpen is the token for [
+	statusOpen
+	//statusClose is is the token for ]
+	statusClose
+
+	slash        // /
+	semicolon    // ;
+	colon        // :
+	asterisk     // *
+	comma        // ,
+	dot          // .
+	hashtag      // #
+	bracket      // ( )
+	currencySign // $ €
+	paragraph    // §
+	ampersand    // &
+	equals       // =
+	tilde        // ~
+	at           // @
+	percent      // %
+	dash         // -
+	underscore   // _
+)
+
+var endoffile = rune(0)
+
+//legacyScanner represents the rune based lexical scanner
+type legacyScanner struct {
+	*bufio.Reader
+}
+
+//newLegacyScanner returns a new instance of legacyScanner
+func newLegacyScanner(r io.Reader) *legacyScanner {
+	return &legacyScanner{bufio.NewReader(r)}
+}
+
+//read reads the next rune from the buffered reader.
+//Returns the rune(0) if an error occurs(or io.eof is returned).
+func (s *legacyScanner) read() rune {
+	r, _, err := s.ReadRune()
+	if err != nil {
+		return endoffile
+	}
+
+	return r
+}
+
+//Scan returns the next token and its value
+func (s *legacyScanner) Scan() (tok legacyToken, lit string) {
+	ch := s.read()
+
+	// If we see whitespace then consume all contiguous whitespace.
+	// If we see a letter then consume as an ident or reserved word.
+	if isWhitespace(ch) {
+		s.UnreadRune()
+		return s.scanWhitespace()
+	} else if isLetter(ch) || isDigit(ch) {
+		s.UnreadRune()
+		return s.scanIdent()
+	}
+
+	//Otherwise read individual character
+	switch ch {
+	case '#':
+		return hashtag, "#"
+	case '[':
+		return statusOpen, "["
+	case ']':
+		return statusClose, "]"
+	case ',':
+		return comma, ","
+	case '.':
+		return dot, "."
+	case ':':
+		return colon, ":"
+	case ';':
+		return semicolon, ";"
+	case '/':
+		return slash, "/"
+	case '*':
+		return asterisk, "*"
+	case '(':
+		fallthrough
+	case ')':
+		return bracket, string(ch)
+	case '~':
+		return tilde, "~"
+	case '€':
+		fallthrough
+	case '$':
+		fallthrough
+	case '£':
+		fallthrough
+	case '¥':
+		return currencySign, string(ch)
+	case '§':
+		return paragraph, "§"
+	case '&':
+		return ampersand, "&"
+	case '=':
+		return equals, "="
+	case '@':
+		return at, "@"
+	case '%':
+		return percent, "%"
+	case '-':
+		return dash, "-"
+	case '_':
+		return underscore, "_"
+	case endoffile:
+		return eof, string(ch)
+	}
+
+	return illegal, string(ch)
+}
+
+func (s *legacyScanner) scanWhitespace() (tok legacyToken, lit string) {
+	//Create buffer and read the current character into it
+	var buf bytes.Buffer
+	buf.WriteRune(s.read())
+
+	//Read every subsequent whitespace into the buffer.
+	//Non Whitspace Characters and eof will cause the loop to exit
+	for {
+		if ch := s.read(); ch == eof {
+			break
+		} else if !isWhitespace(ch) {
+			s.UnreadRune()
+			break
+		} else {
+			buf.WriteRune(ch)
+		}
+	}
+
+	return ws, buf.String()
+}
+
+func (s *legacyScanner) scanIdent() (tok legacyToken, lit string) {
+	var buf bytes.Buffer
+	buf.WriteRune(s.read())
+
+	//Read every subsequent ident character into the buffer.
+	//Non ident Characters and eof will cause the loop to exit
+
+	for {
+		if ch := s.read(); ch == eof {
+			break
+		} else if !isLetter(ch) && !isDigit(ch) {
+			s.UnreadRune()
+			break
+		} else {
+			buf.WriteRune(ch)
+		}
+	}
+
+	return ident, buf.String()
+}
+
+func isWhitespace(ch rune) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n'
+}
+
+func isLetter(ch rune) bool {
+	return (ch >= 'a' && ch <= 'z') ||
+		(ch >= 'A' && ch <= 'Z') ||
+		ch == 'ä' || ch == 'Ö' ||
+		ch == 'ö' || ch == 'Ä' ||
+		ch == 'ü' || ch == 'Ü' ||
+		ch == 'ß'
+}
+
+func isDigit(ch rune) bool {
+	return ch >= '0' && ch <= '9'
+}
+//legacyParser parses the tokens of a legacyScanner
+type legacyParser struct {
+	*legacyScanner
+}
+
+//newLegacyParser returns an instance of a new legacyParser
+func newLegacyParser(r io.Reader) *legacyParser {
+	return &legacyParser{newLegacyScanner(r)}
+}
+
+func (p *legacyParser) scanIgnoreWhitespace() (tok legacyToken, lit string) {
+	tok, lit = p.Scan()
+	if tok == ws {
+		tok, lit = p.scanIgnoreWhitespace()
+	}
+
+	return
+}
+
+// Parse returns a the next task.Day struct that can be parsed from the input or an error if no new task.Day can be
+// parsed
+func (p *legacyParser) Parse() (task.Day, error) {
+	var taskDay task.Day
+
+	tok, lit := p.scanIgnoreWhitespace()
+	if tok != hashtag && tok != eof {
+		return taskDay, fmt.Errorf("found %q, expected #", lit)
+	}
+
+	if tok == eof {
+		return taskDay, nil
+	}
+
+	var buf bytes.Buffer
+	for {
+		//Read a field
+		tok, lit := p.Scan()
+
+		if tok != ident && tok != dot && tok != ws {
+			return taskDay, fmt.Errorf("found %q, expected field or dot", lit)
+		}
+
+		if tok == ws && buf.Len() > 0 {
+			dateString := strings.Trim(buf.String(), " ")
+			dueTime, err := time.Parse(Timeformat, dateString)
+			if err != nil {
+				return taskDay, err
+			}
+			taskDay.Date = dueTime
+			break
+		}
+
+		buf.WriteString(lit)
+	}
+	buf.Reset()
+
+	for {
+		todo := &task.Todo{}
+
+		tok, lit := p.scanIgnoreWhitespace()
+		if tok == hashtag || tok == eof {
+			return taskDay, nil
+		}
+
+		if tok != dash {
+			return taskDay, fmt.Errorf("found %q, expected -", lit)
+		}
+
+		tok, lit = p.scanIgnoreWhitespace()
+		if tok != statusOpen {
+			return taskDay, fmt.Errorf("found %q, expected [", lit)
+		}
+
+		if tok, lit = p.Scan(); tok != ws && tok != ident {
+			return taskDay, fmt.Errorf("found %q, expected ws or X", lit)
+		}
+
+		if tok == ident {
+			todo.Complete = true
+		} else {
+			todo.Complete = false
+		}
+
+		if tok, lit := p.Scan(); tok != statusClose {
+			return taskDay, fmt.Errorf("found %q, expected ]", lit)
+		}
+
+		var buf bytes.Buffer
+
+		for {
+			//Read a field
+			tok, lit := p.Scan()
+
+			if !isLegacyDescriptionToken(tok) && tok != dash && tok != eof && tok != hashtag {
+				return taskDay, fmt.Errorf("found %q, expected field", lit)
+			}
+
+			if tok == eof || tok == hashtag {
+				todo.Description = strings.Trim(buf.String(), " \n")
+				taskDay.Todos.InsertTodo(*todo)
+				p.UnreadRune()
+				return taskDay, nil
+			}
+
+			if tok == dash {
+				p.UnreadRune()
+				break
+			}
+
+			buf.WriteString(lit)
+		}
+
+		todo.Description = strings.Trim(buf.String(), " \n")
+		taskDay.Todos.InsertTodo(*todo)
+	}
+}
+
+func isLegacyDescriptionToken(tok legacyToken) bool {
+	return tok == ws ||
+		tok == ident ||
+		tok == dot ||
+		tok == comma ||
+		tok == slash ||
+		tok == semicolon ||
+		tok == colon ||
+		tok == asterisk ||
+		tok == bracket ||
+		tok == currencySign ||
+		tok == paragraph ||
+		tok == underscore
+}
+
+// benchmarkInput returns a todo file with 100k todos spread over 1000 days
+func benchmarkInput() string {
+	var buf bytes.Buffer
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	for d := 0; d < 1000; d++ {
+		buf.WriteString("\n# " + start.AddDate(0, 0, -d).Format(Timeformat) + "\n\n")
+		for i := 0; i < 100; i++ {
+			if i%3 == 0 {
+				fmt.Fprintf(&buf, "- [x] Todo %d  \n", i)
+			} else {
+				fmt.Fprintf(&buf, "- [ ] Todo %d  \n", i)
+			}
+		}
+	}
+	return buf.String()
+}
+
+func BenchmarkParser_Parse(b *testing.B) {
+	input := benchmarkInput()
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		p := NewParser(strings.NewReader(input))
+		for {
+			day, err := p.Parse()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if day.Date.IsZero() {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkEach(b *testing.B) {
+	input := benchmarkInput()
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		err := Each(strings.NewReader(input), func(task.Day) error { return nil })
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLegacyParser_Parse(b *testing.B) {
+	input := benchmarkInput()
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		p := newLegacyParser(strings.NewReader(input))
+		for {
+			day, err := p.Parse()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if day.Date.IsZero() {
+				break
+			}
+		}
+	}
+}
@@ -1,199 +1,188 @@
 package parse
 
 import (
-	"bufio"
 	"bytes"
-	"io"
 )
 
-//Token identifies the type of data that was read
-type Token int
+// lineKind identifies the type of a line that was read
+type lineKind int
 
 const (
+	// blankLine is a line that contains nothing but whitespace
+	blankLine lineKind = iota
 
-	//illegal represents anything that cannot be identified by any other token
-	illegal = iota
-
-	//eof represents the end of file token
-	eof
-
-	//ws identifies a whitespace
-	ws
-
-	//ident are identifiers of todos and dates
-	ident
-
-	//statusOpen is the token for [
-	statusOpen
-	//statusClose is is the token for ]
-	statusClose
-
-	slash        // /
-	semicolon    // ;
-	colon        // :
-	asterisk     // *
-	comma        // ,
-	dot          // .
-	hashtag      // #
-	bracket      // ( )
-	currencySign // $ €
-	paragraph    // §
-	ampersand    // &
-	equals       // =
-	tilde        // ~
-	at           // @
-	percent      // %
-	dash         // -
-	underscore   // _
-)
+	// headingLine is a Markdown heading of any level like "# dd.mm.yy" or "## 2017-07-17".
+	// It may be followed by a todo on the same line.
+	headingLine
 
-var endoffile = rune(0)
+	// todoLine is a task list item like "- [ ] description", "* [x] description" or "1. [ ] description"
+	todoLine
 
-//Scanner represents a lexical scanner
-type scanner struct {
-	*bufio.Reader
-}
+	// itemLine is a list item that is not a task
+	itemLine
 
-//NewScanner returns a new instance of Scanner
-func NewScanner(r io.Reader) *scanner {
-	return &scanner{bufio.NewReader(r)}
-}
+	// fenceLine opens or closes a fenced code block
+	fenceLine
 
-//read reads the next rune from the buffered reader.
-//Returns the rune(0) if an error occurs(or io.eof is returned).
-func (s *scanner) read() rune {
-	r, _, err := s.ReadRune()
-	if err != nil {
-		return endoffile
-	}
+	// conflictStartLine, conflictSeparatorLine and conflictEndLine are the markers "<<<<<<<", "=======" and
+	// ">>>>>>>" around the two versions of todos that could not be merged. Outside of a conflict they are text.
+	conflictStartLine
+	conflictSeparatorLine
+	conflictEndLine
 
-	return r
-}
+	// textLine is any other line. Directly after a todo it continues the todo's description, otherwise it is prose.
+	textLine
+)
 
-//Scan returns the next token and its value
-func (s *scanner) Scan() (tok Token, lit string) {
-	ch := s.read()
-
-	// If we see whitespace then consume all contiguous whitespace.
-	// If we see a letter then consume as an ident or reserved word.
-	if isWhitespace(ch) {
-		s.UnreadRune()
-		return s.scanWhitespace()
-	} else if isLetter(ch) || isDigit(ch) {
-		s.UnreadRune()
-		return s.scanIdent()
-	}
+// line is the result of lexing a single line of input. Its byte slices point into the scanned line and are
+// only valid until the next line is read.
+type line struct {
+	kind lineKind
 
-	//Otherwise read individual character
-	switch ch {
-	case '#':
-		return hashtag, "#"
-	case '[':
-		return statusOpen, "["
-	case ']':
-		return statusClose, "]"
-	case ',':
-		return comma, ","
-	case '.':
-		return dot, "."
-	case ':':
-		return colon, ":"
-	case ';':
-		return semicolon, ";"
-	case '/':
-		return slash, "/"
-	case '*':
-		return asterisk, "*"
-	case '(':
-		fallthrough
-	case ')':
-		return bracket, string(ch)
-	case '~':
-		return tilde, "~"
-	case '€':
-		fallthrough
-	case '$':
-		fallthrough
-	case '£':
-		fallthrough
-	case '¥':
-		return currencySign, string(ch)
-	case '§':
-		return paragraph, "§"
-	case '&':
-		return ampersand, "&"
-	case '=':
-		return equals, "="
-	case '@':
-		return at, "@"
-	case '%':
-		return percent, "%"
-	case '-':
-		return dash, "-"
-	case '_':
-		return underscore, "_"
-	case endoffile:
-		return eof, string(ch)
-	}
+	// date is the first word of a headingLine if it looks like a date
+	date []byte
 
-	return illegal, string(ch)
+	// hasTodo is true for todoLines and for headingLines which are followed by a todo
+	hasTodo  bool
+	complete bool
+	desc     []byte
+
+	// text is the trimmed content of a textLine and the text after the date of a headingLine without todo
+	text []byte
 }
 
-func (s *scanner) scanWhitespace() (tok Token, lit string) {
-	//Create buffer and read the current character into it
-	var buf bytes.Buffer
-	buf.WriteRune(s.read())
+// lexLine splits a single line without its line ending into its parts
+func lexLine(b []byte) (l line) {
+	b = bytes.TrimSpace(b)
+
+	switch {
+	case len(b) == 0:
+		l.kind = blankLine
+	case isConflictMarker(b, '<'):
+		l.kind = conflictStartLine
+		l.text = b
+	case isConflictMarker(b, '='):
+		l.kind = conflictSeparatorLine
+		l.text = b
+	case isConflictMarker(b, '>'):
+		l.kind = conflictEndLine
+		l.text = b
+	case bytes.HasPrefix(b, []byte("```")) || bytes.HasPrefix(b, []byte("~~~")):
+		l.kind = fenceLine
+	case b[0] == '#':
+		rest := bytes.TrimLeft(b, "#")
+		spaced := len(rest) == 0 || rest[0] == ' ' || rest[0] == '\t'
+		rest = bytes.TrimLeft(rest, " \t")
+		end := bytes.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
 
-	//Read every subsequent whitespace into the buffer.
-	//Non Whitspace Characters and eof will cause the loop to exit
-	for {
-		if ch := s.read(); ch == eof {
+		isDate := looksLikeDate(rest[:end])
+		if len(b)-len(bytes.TrimLeft(b, "#")) > 6 || !spaced && !isDate {
+			l.kind = textLine
+			l.text = b
 			break
-		} else if !isWhitespace(ch) {
-			s.UnreadRune()
+		}
+
+		l.kind = headingLine
+		if isDate {
+			l.date = rest[:end]
+			if lexTodo(bytes.TrimLeft(rest[end:], " \t"), &l); !l.hasTodo {
+				l.text = bytes.TrimLeft(rest[end:], " \t")
+			}
+		}
+	default:
+		if _, ok := trimBullet(b); !ok {
+			l.kind = textLine
+			l.text = b
 			break
-		} else {
-			buf.WriteRune(ch)
+		}
+		l.kind = itemLine
+		if lexTodo(b, &l); l.hasTodo {
+			l.kind = todoLine
 		}
 	}
 
-	return ws, buf.String()
+	return l
 }
 
-func (s *scanner) scanIdent() (tok Token, lit string) {
-	var buf bytes.Buffer
-	buf.WriteRune(s.read())
-
-	//Read every subsequent ident character into the buffer.
-	//Non ident Characters and eof will cause the loop to exit
+// lexTodo reads a task list item like "- [ ] description" from b into l. If b is no task list item l is left
+// unchanged.
+func lexTodo(b []byte, l *line) {
+	b, ok := trimBullet(b)
+	if !ok || len(b) < 3 || b[0] != '[' || b[2] != ']' {
+		return
+	}
+	if len(b) > 3 && b[3] != ' ' && b[3] != '\t' {
+		return
+	}
 
-	for {
-		if ch := s.read(); ch == eof {
-			break
-		} else if !isLetter(ch) && !isDigit(ch) {
-			s.UnreadRune()
-			break
-		} else {
-			buf.WriteRune(ch)
-		}
+	switch b[1] {
+	case ' ':
+		l.complete = false
+	case 'x', 'X':
+		l.complete = true
+	default:
+		return
 	}
 
-	return ident, buf.String()
+	l.hasTodo = true
+	l.desc = bytes.TrimSpace(b[3:])
 }
 
-func isWhitespace(ch rune) bool {
-	return ch == ' ' || ch == '\t' || ch == '\n'
+// trimBullet removes a list marker like "-", "*", "+", "1." or "1)" and the following whitespace from b
+func trimBullet(b []byte) ([]byte, bool) {
+	i := 0
+	for i < len(b) && b[i] >= '0' && b[i] <= '9' {
+		i++
+	}
+
+	switch {
+	case i == 0 && len(b) > 0 && (b[0] == '-' || b[0] == '*' || b[0] == '+'):
+		i = 1
+	case i > 0 && i < len(b) && (b[i] == '.' || b[i] == ')'):
+		i++
+	default:
+		return b, false
+	}
+
+	if i < len(b) && b[i] != ' ' && b[i] != '\t' {
+		return b, false
+	}
+	return bytes.TrimLeft(b[i:], " \t"), true
 }
 
-func isLetter(ch rune) bool {
-	return (ch >= 'a' && ch <= 'z') ||
-		(ch >= 'A' && ch <= 'Z') ||
-		ch == 'ä' || ch == 'Ö' ||
-		ch == 'ö' || ch == 'Ä' ||
-		ch == 'ü' || ch == 'Ü' ||
-		ch == 'ß'
+// isConflictMarker returns true if b starts with seven times c followed by nothing or a space, like the markers
+// written by towg and git
+func isConflictMarker(b []byte, c byte) bool {
+	if len(b) < 7 || len(b) > 7 && b[7] != ' ' {
+		return false
+	}
+	for _, m := range b[:7] {
+		if m != c {
+			return false
+		}
+	}
+	return true
 }
 
-func isDigit(ch rune) bool {
-	return ch >= '0' && ch <= '9'
+// looksLikeDate returns true if b has the shape of one of the date formats, with digits where the format has
+// digits and the same separators. Headings like this are treated as dates, so that a typo in a date is reported
+// instead of silently turning the day into prose. Other headings, like a version number "1.2.3", are prose.
+func looksLikeDate(b []byte) bool {
+	digit := func(c byte) bool { return c >= '0' && c <= '9' }
+	for _, format := range dateFormats {
+		if len(b) != len(format) {
+			continue
+		}
+		i := 0
+		for i < len(b) && digit(b[i]) == digit(format[i]) && (digit(b[i]) || b[i] == format[i]) {
+			i++
+		}
+		if i == len(b) {
+			return true
+		}
+	}
+	return false
 }
@@ -1,145 +1,274 @@
 package parse
 
 import (
-	"bytes"
+	"bufio"
 	"fmt"
 	"github.com/FChris/towg/task"
 	"io"
-	"strings"
 	"time"
 )
 
 //Timeformat describes the format used to parse dates
 const Timeformat string = "02.01.06"
 
-//Parser provides the functionality to parse files that were tokenized by lexer
+// maxLineLength is the longest line the parser accepts
+const maxLineLength = 1024 * 1024
+
+// dateFormats are the layouts accepted for date headings
+var dateFormats = []string{Timeformat, "2006-01-02", "02.01.2006", "2006/01/02"}
+
+//Parser provides the functionality to parse todo files line by line.
+//Only the day that is currently parsed is kept in memory, so arbitrarily large input can be streamed through
+//Next and Day or Each.
+//
+//Besides the files written by towg the parser reads GitHub flavoured Markdown task lists: date headings of any
+//level, "-", "*", "+" and numbered bullets, nested lists and surrounding prose, which is ignored.
+//
+//Todos that could not be merged are written between conflict markers like git does:
+//
+//	<<<<<<< ours
+//	- [x] Todo
+//	=======
+//	- [ ] (A) Todo
+//	>>>>>>> theirs
+//
+//Only the first version of such a conflict is read. The lines the conflicts start at are reported by Conflicts.
+//
+//Lines which are lost when towg writes the todos back, like prose, headings without date and todos that are not below
+//a date heading, are reported by Foreign.
 type Parser struct {
-	*scanner
+	// DefaultDate is the date of todos that do not follow a date heading, like the checklist of a README.
+	// Such todos are skipped if DefaultDate is zero.
+	DefaultDate time.Time
+	// Order is the order of the todos of every day. In task.ManualOrder they are kept in the order they are written
+	// in.
+	Order task.TodoOrder
+
+	lines  *bufio.Scanner
+	lineNo int
+
+	// section is the day the todos that are read belong to. It is nil while todos are skipped.
+	section *task.Day
+	dated   bool
+	started bool
+	inFence bool
+
+	// inConflict is true between the markers of a conflict and inTheirs is true after its separator
+	inConflict bool
+	inTheirs   bool
+	conflicts  []int
+
+	// foreign are the lines which are not written back by towg
+	foreign []int
+
+	// todo is the last todo that was read with the whole text of the todo as description.
+	// It is inserted into its day once it can no longer be continued.
+	todo    task.Todo
+	hasTodo bool
+
+	day task.Day
+	err error
 }
 
 //NewParser returns an instance of a new parser
 func NewParser(r io.Reader) *Parser {
-	return &Parser{NewScanner(r)}
-}
-
-func (p *Parser) scanIgnoreWhitespace() (tok Token, lit string) {
-	tok, lit = p.Scan()
-	if tok == ws {
-		tok, lit = p.scanIgnoreWhitespace()
-	}
-
-	return
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+	return &Parser{lines: lines}
 }
 
 // Parse returns a the next task.Day struct that can be parsed from the input or an error if no new task.Day can be
-// parsed
+// parsed. Once the input is exhausted a Day with a zero Date is returned.
 func (p *Parser) Parse() (task.Day, error) {
-	var taskDay task.Day
-
-	tok, lit := p.scanIgnoreWhitespace()
-	if tok != hashtag && tok != eof {
-		return taskDay, fmt.Errorf("found %q, expected #", lit)
+	if !p.Next() {
+		return task.Day{}, p.Err()
 	}
+	return p.Day(), nil
+}
 
-	if tok == eof {
-		return taskDay, nil
+// Next advances the parser to the next day, which is then available through Day.
+// It returns false once the input is exhausted or an error occurred, which is reported by Err.
+func (p *Parser) Next() bool {
+	if p.err != nil {
+		return false
 	}
 
-	var buf bytes.Buffer
-	for {
-		//Read a field
-		tok, lit := p.Scan()
+	p.day, p.err = p.parseDay()
+	return p.err == nil && !p.day.Date.IsZero()
+}
 
-		if tok != ident && tok != dot && tok != ws {
-			return taskDay, fmt.Errorf("found %q, expected field or dot", lit)
-		}
+// Day returns the day read by the last call to Next
+func (p *Parser) Day() task.Day {
+	return p.day
+}
 
-		if tok == ws && buf.Len() > 0 {
-			dateString := strings.Trim(buf.String(), " ")
-			dueTime, err := time.Parse(Timeformat, dateString)
-			if err != nil {
-				return taskDay, err
-			}
-			taskDay.Date = dueTime
-			break
-		}
+// Err returns the first error that occurred while parsing
+func (p *Parser) Err() error {
+	return p.err
+}
 
-		buf.WriteString(lit)
-	}
-	buf.Reset()
+// Conflicts returns the lines of the "<<<<<<<" markers read so far
+func (p *Parser) Conflicts() []int {
+	return p.conflicts
+}
 
-	for {
-		todo := &task.Todo{}
+// Foreign returns the lines read so far which are not part of a todo below a date heading, like prose, headings
+// without date, list items without checkbox, code blocks and undated todos. They are lost if the todos are written
+// back in the format of towg.
+func (p *Parser) Foreign() []int {
+	return p.foreign
+}
 
-		tok, lit := p.scanIgnoreWhitespace()
-		if tok == hashtag || tok == eof {
-			return taskDay, nil
+// Each parses r and calls fn for every day in the order they appear in the input.
+// It stops at the first error returned by either the parser or fn.
+func Each(r io.Reader, fn func(task.Day) error) error {
+	p := NewParser(r)
+	for p.Next() {
+		if err := fn(p.Day()); err != nil {
+			return err
 		}
+	}
+	return p.Err()
+}
 
-		if tok != dash {
-			return taskDay, fmt.Errorf("found %q, expected -", lit)
-		}
+func (p *Parser) parseDay() (task.Day, error) {
+	if !p.started {
+		p.startSection(nil)
+		p.started = true
+	}
 
-		tok, lit = p.scanIgnoreWhitespace()
-		if tok != statusOpen {
-			return taskDay, fmt.Errorf("found %q, expected [", lit)
-		}
+	for p.lines.Scan() {
+		p.lineNo++
+		l := lexLine(p.lines.Bytes())
 
-		if tok, lit = p.Scan(); tok != ws && tok != ident {
-			return taskDay, fmt.Errorf("found %q, expected ws or X", lit)
+		if p.inFence {
+			p.inFence = l.kind != fenceLine
+			continue
 		}
-
-		if tok == ident {
-			todo.Complete = true
-		} else {
-			todo.Complete = false
+		if !p.inConflict && (l.kind == conflictSeparatorLine || l.kind == conflictEndLine) {
+			l.kind = textLine
 		}
+		if p.inTheirs && l.kind != conflictEndLine {
+			continue
+		}
+
+		switch l.kind {
+		case blankLine:
+			p.flushTodo()
+		case textLine:
+			if p.hasTodo {
+				p.todo.Description += "\n" + string(l.text)
+			} else {
+				p.foreign = append(p.foreign, p.lineNo)
+			}
+		case fenceLine:
+			p.flushTodo()
+			p.inFence = true
+			p.foreign = append(p.foreign, p.lineNo)
+		case conflictStartLine:
+			p.flushTodo()
+			if p.inConflict {
+				return task.Day{}, p.errorf("found conflict inside of the conflict in line %d", p.conflicts[len(p.conflicts)-1])
+			}
+			p.inConflict = true
+			p.conflicts = append(p.conflicts, p.lineNo)
+		case conflictSeparatorLine:
+			p.flushTodo()
+			p.inTheirs = true
+		case conflictEndLine:
+			p.flushTodo()
+			p.inConflict, p.inTheirs = false, false
+		case itemLine:
+			p.flushTodo()
+			p.foreign = append(p.foreign, p.lineNo)
+		case todoLine:
+			p.flushTodo()
+			p.setTodo(l)
+			if !p.dated {
+				p.foreign = append(p.foreign, p.lineNo)
+			}
+		case headingLine:
+			p.flushTodo()
+			if l.date == nil || len(l.text) > 0 {
+				p.foreign = append(p.foreign, p.lineNo)
+			}
+			var date *time.Time
+			if l.date != nil {
+				d, err := parseDate(l.date)
+				if err != nil {
+					return task.Day{}, p.errorf("%s", err)
+				}
+				date = &d
+			}
 
-		if tok, lit := p.Scan(); tok != statusClose {
-			return taskDay, fmt.Errorf("found %q, expected ]", lit)
+			day, ok := p.startSection(date)
+			if l.hasTodo {
+				p.setTodo(l)
+			}
+			if ok {
+				return day, nil
+			}
 		}
+	}
 
-		var buf bytes.Buffer
+	if err := p.lines.Err(); err != nil {
+		return task.Day{}, p.errorf("%s", err)
+	}
 
-		for {
-			//Read a field
-			tok, lit := p.Scan()
+	if p.inConflict {
+		return task.Day{}, p.errorf("conflict in line %d is not closed", p.conflicts[len(p.conflicts)-1])
+	}
 
-			if !isDescriptionToken(tok) && tok != dash && tok != eof && tok != hashtag {
-				return taskDay, fmt.Errorf("found %q, expected field", lit)
-			}
+	p.flushTodo()
+	day, _ := p.startSection(nil)
+	p.section = nil
+	return day, nil
+}
 
-			if tok == eof || tok == hashtag {
-				todo.Description = strings.Trim(buf.String(), " \n")
-				taskDay.Todos.InsertTodo(*todo)
-				p.UnreadRune()
-				return taskDay, nil
-			}
+// startSection finishes the current section and starts a new one for the given date. If date is nil the new
+// section collects undated todos. It returns the finished section if it has to be reported as a day.
+func (p *Parser) startSection(date *time.Time) (day task.Day, ok bool) {
+	if p.section != nil && (p.dated || len(p.section.Todos) > 0) {
+		day, ok = *p.section, true
+	}
 
-			if tok == dash {
-				p.UnreadRune()
-				break
-			}
+	p.dated = date != nil
+	switch {
+	case date != nil:
+		p.section = &task.Day{Date: *date}
+	case !p.DefaultDate.IsZero():
+		p.section = &task.Day{Date: p.DefaultDate}
+	default:
+		p.section = nil
+	}
+	return
+}
 
-			buf.WriteString(lit)
+func parseDate(b []byte) (date time.Time, err error) {
+	for _, format := range dateFormats {
+		if date, err = time.Parse(format, string(b)); err == nil {
+			return
 		}
+	}
+	return date, fmt.Errorf("found %q, expected date as dd.mm.yy or yyyy-mm-dd", b)
+}
 
-		todo.Description = strings.Trim(buf.String(), " \n")
-		taskDay.Todos.InsertTodo(*todo)
+func (p *Parser) setTodo(l line) {
+	p.todo = task.Todo{Description: string(l.desc), Complete: l.complete}
+	p.hasTodo = true
+}
+
+// flushTodo inserts the pending todo into the current section. It is dropped if the section is skipped.
+func (p *Parser) flushTodo() {
+	if !p.hasTodo {
+		return
+	}
+	if p.section != nil {
+		p.section.Todos.InsertTodoInOrder(task.ParseTodo(p.todo.Description, p.todo.Complete), p.Order)
 	}
+	p.hasTodo = false
 }
 
-func isDescriptionToken(tok Token) bool {
-	return tok == ws ||
-		tok == ident ||
-		tok == dot ||
-		tok == comma ||
-		tok == slash ||
-		tok == semicolon ||
-		tok == colon ||
-		tok == asterisk ||
-		tok == bracket ||
-		tok == currencySign ||
-		tok == paragraph ||
-		tok == underscore
+func (p *Parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: "+format, append([]interface{}{p.lineNo}, args...)...)
 }
@@ -58,3 +58,164 @@ func TestParseMultilineString(t *testing.T) {
 	testDay2 := task.Day{Date: testDate2, Todos: testTodoList2}
 	assert.Equal(t, testDay2, day, "Test Day 2 does not equal actual parsed day")
 }
+
+func TestEach(t *testing.T) {
+	input := "\n# 01.01.20\n\n- [x] Test - with dash  \n- [ ] Test String\n  continued\n\n# 02.01.20 - [ ] Test String2\n"
+
+	var days []task.Day
+	err := Each(strings.NewReader(input), func(day task.Day) error {
+		days = append(days, day)
+		return nil
+	})
+	assert.Equal(t, nil, err, "Error is not nil")
+
+	testDate1, err := time.Parse(Timeformat, "01.01.20")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+	testDate2, err := time.Parse(Timeformat, "02.01.20")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+
+	expectedDays := []task.Day{
+		{Date: testDate1, Todos: task.TodoList{
+			{Description: "Test - with dash", Complete: true},
+			{Description: "Test String\ncontinued", Complete: false},
+		}},
+		{Date: testDate2, Todos: task.TodoList{{Description: "Test String2", Complete: false}}},
+	}
+	assert.Equal(t, expectedDays, days, "Streamed days do not equal the expected days")
+}
+
+func TestParseError(t *testing.T) {
+	p := NewParser(strings.NewReader("# 01.01.20\n- [ ] Test String\n\n# 32.01.20\n- [ ] Test String2\n"))
+	_, err := p.Parse()
+	assert.EqualError(
+		t,
+		err,
+		`line 4: found "32.01.20", expected date as dd.mm.yy or yyyy-mm-dd`,
+		"Parse error does not name the line")
+
+	assert.False(t, p.Next(), "Next continues after an error")
+	assert.Equal(t, err, p.Err(), "Err does not return the parse error")
+}
+
+func TestParseVersionHeading(t *testing.T) {
+	p := NewParser(strings.NewReader("# Changelog\n\n## 1.2.3\n\n- Fixed a bug\n\n# 17.07.17\n\n- [ ] Todo A  \n"))
+	days, err := p.Parse()
+	assert.Equal(t, nil, err, "Heading with a version number is reported as a wrong date")
+	assert.Equal(t, task.TodoList{{Description: "Todo A"}}, days.Todos, "Todos after a version number are not read")
+	assert.Equal(t, []int{1, 3, 5}, p.Foreign(), "Heading with a version number is not reported as foreign")
+}
+
+func TestParseMarkdown(t *testing.T) {
+	input := "Some notes about the project.\n" +
+		"* [ ] Undated todo\n\n" +
+		"## 2017-07-17 Monday\n\n" +
+		"Prose before the list.\n\n" +
+		"* [x] Todo 25\n" +
+		"  + [ ] Nested todo\n" +
+		"1. [X] Numbered todo\n" +
+		"- A plain list item\n" +
+		"- [link](http://example.com)\n" +
+		"```\n- [ ] Todo in a code block\n```\n" +
+		"### Notes\n\n" +
+		"- [ ] Todo under a heading without date\n"
+
+	testDate, err := time.Parse(Timeformat, "17.07.17")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+	expectedDay := task.Day{Date: testDate, Todos: task.TodoList{
+		{Description: "Numbered todo", Complete: true},
+		{Description: "Todo 25", Complete: true},
+		{Description: "Nested todo", Complete: false},
+	}}
+
+	var days []task.Day
+	err = Each(strings.NewReader(input), func(day task.Day) error {
+		days = append(days, day)
+		return nil
+	})
+	assert.Equal(t, nil, err, "Error is not nil")
+	assert.Equal(t, []task.Day{expectedDay}, days, "Markdown was not parsed into the expected days")
+
+	defaultDate := testDate.AddDate(0, 0, 1)
+	p := NewParser(strings.NewReader(input))
+	p.DefaultDate = defaultDate
+	days = nil
+	for p.Next() {
+		days = append(days, p.Day())
+	}
+	assert.Equal(t, nil, p.Err(), "Error is not nil")
+	assert.Equal(
+		t,
+		[]task.Day{
+			{Date: defaultDate, Todos: task.TodoList{{Description: "Undated todo", Complete: false}}},
+			expectedDay,
+			{Date: defaultDate, Todos: task.TodoList{{Description: "Todo under a heading without date", Complete: false}}},
+		},
+		days,
+		"Undated todos were not assigned to the default date")
+	assert.Equal(t, []int{1, 2, 4, 6, 11, 12, 13, 16, 18}, p.Foreign(), "Lines towg does not write are not reported")
+}
+
+func TestParseForeign(t *testing.T) {
+	p := NewParser(strings.NewReader("\n# 17.07.17\n\n- [x] (A) Call Mom  \n  created:2017-07-01  \n" +
+		"<<<<<<< ours\n- [x] Todo B  \n=======\nSome text  \n>>>>>>> theirs\n\n# 18.07.17 - [ ] Todo C\n"))
+	for p.Next() {
+	}
+	assert.Equal(t, nil, p.Err(), "Error is not nil")
+	assert.Equal(t, []int(nil), p.Foreign(), "Lines of a file written by towg are reported as foreign")
+}
+
+func TestParseTodoFields(t *testing.T) {
+	p := NewParser(strings.NewReader("# 17.07.17\n- [x] (A) Call Mom +family @phone\n  created:2017-07-01 completed:2017-07-17\n"))
+	day, err := p.Parse()
+	assert.Equal(t, nil, err, "Error is not nil")
+
+	testDate1, err := time.Parse(task.DateFormat, "2017-07-01")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+	testDate2, err := time.Parse(task.DateFormat, "2017-07-17")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+
+	assert.Equal(
+		t,
+		task.TodoList{{
+			Description: "Call Mom +family @phone",
+			Complete:    true,
+			Priority:    "A",
+			Created:     testDate1,
+			Completed:   testDate2,
+		}},
+		day.Todos,
+		"Priority and dates were not parsed into the fields of the todo")
+}
+
+func TestParseTodosDifferingInFields(t *testing.T) {
+	p := NewParser(strings.NewReader("# 17.07.17\n- [ ] (A) Call  \n- [ ] (B) Call  \n- [ ] Meet created:2017-01-01 Bob  \n"))
+	day, err := p.Parse()
+	assert.Equal(t, nil, err, "Error is not nil")
+	assert.Equal(
+		t,
+		task.TodoList{{Description: "Call", Priority: "A"}, {Description: "Call", Priority: "B"},
+			{Description: "Meet created:2017-01-01 Bob"}},
+		day.Todos,
+		"Todos differing only in their priority were merged or fields inside the text were moved")
+	for _, todo := range day.Todos {
+		assert.Contains(t, []string{"(A) Call", "(B) Call", "Meet created:2017-01-01 Bob"}, todo.Text(),
+			"Text of the todo differs from the line it was read from")
+	}
+}
+
+func TestParseConflicts(t *testing.T) {
+	p := NewParser(strings.NewReader("# 17.07.17\n\n- [ ] Todo A  \n<<<<<<< ours\n- [x] Todo B  \n=======\n" +
+		"- [ ] (A) Todo B  \n- [ ] Todo C  \n>>>>>>> theirs\n- [ ] Todo D  \n\n=======\n"))
+	day, err := p.Parse()
+	assert.Equal(t, nil, err, "Error is not nil")
+	assert.Equal(
+		t,
+		task.TodoList{{Description: "Todo B", Complete: true}, {Description: "Todo A"}, {Description: "Todo D"}},
+		day.Todos,
+		"Only the first version of a conflict is not read")
+	assert.Equal(t, []int{4}, p.Conflicts(), "Lines of the conflicts were not reported")
+
+	p = NewParser(strings.NewReader("# 17.07.17\n<<<<<<< ours\n- [x] Todo B  \n=======\n"))
+	_, err = p.Parse()
+	assert.EqualError(t, err, "line 4: conflict in line 2 is not closed", "Unclosed conflict is not reported")
+}
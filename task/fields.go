@@ -0,0 +1,117 @@
+package task
+
+import (
+	"strings"
+	"time"
+)
+
+// DateFormat is the layout of the dates stored in the text of a todo
+const DateFormat = "2006-01-02"
+
+const (
+	createdKey   = "created"
+	completedKey = "completed"
+)
+
+// ParseTodo returns the todo described by text, which is everything after the checkbox of a todo line.
+// A leading priority like "(A)" and trailing created: and completed: fields, in the layout Text writes them, are moved
+// from the text into the fields of the Todo. Everything else becomes the description, so that Text returns the
+// same todo again.
+func ParseTodo(text string, complete bool) Todo {
+	todo := Todo{Complete: complete}
+
+	text = strings.TrimSpace(text)
+	if isPriority(text) {
+		todo.Priority = text[1:2]
+		text = strings.TrimSpace(text[3:])
+	}
+	for _, key := range []string{completedKey, createdKey} {
+		i := strings.LastIndexAny(text, " \t\n") + 1
+		if k, _, ok := splitTag(text[i:]); ok && k == key && i > 0 && todo.setDate(text[i:]) {
+			text = strings.TrimRight(text[:i], " \t\n")
+		}
+	}
+
+	todo.Description = text
+	return todo
+}
+
+// setDate sets Created or Completed if word is a created: or completed: field and reports whether it was one
+func (t *Todo) setDate(word string) bool {
+	key, value, ok := splitTag(word)
+	if !ok || (key != createdKey && key != completedKey) {
+		return false
+	}
+	date, err := time.Parse(DateFormat, value)
+	if err != nil {
+		return false
+	}
+
+	if key == createdKey {
+		t.Created = date
+	} else {
+		t.Completed = date
+	}
+	return true
+}
+
+// Text returns the text of the todo as it is written after the checkbox. It is the inverse of ParseTodo.
+func (t Todo) Text() string {
+	text := t.Description
+	if t.Priority != "" {
+		text = "(" + t.Priority + ") " + text
+	}
+	if !t.Created.IsZero() {
+		text += " " + createdKey + ":" + t.Created.Format(DateFormat)
+	}
+	if !t.Completed.IsZero() {
+		text += " " + completedKey + ":" + t.Completed.Format(DateFormat)
+	}
+	return text
+}
+
+// Projects returns the projects of the todo, which are the words of its description starting with '+'
+func (t Todo) Projects() []string {
+	return wordsWithPrefix(t.Description, '+')
+}
+
+// Contexts returns the contexts of the todo, which are the words of its description starting with '@'
+func (t Todo) Contexts() []string {
+	return wordsWithPrefix(t.Description, '@')
+}
+
+// Tags returns all key:value pairs of the description of the todo
+func (t Todo) Tags() map[string]string {
+	tags := make(map[string]string)
+	for _, word := range strings.Fields(t.Description) {
+		if key, value, ok := splitTag(word); ok {
+			tags[key] = value
+		}
+	}
+	return tags
+}
+
+func wordsWithPrefix(text string, prefix byte) []string {
+	var words []string
+	for _, word := range strings.Fields(text) {
+		if len(word) > 1 && word[0] == prefix {
+			words = append(words, word[1:])
+		}
+	}
+	return words
+}
+
+// splitTag splits a word like "due:2017-07-17" into its key and value.
+// Words containing "://" are not tags, so that URLs stay part of the description.
+func splitTag(word string) (key, value string, ok bool) {
+	i := strings.IndexByte(word, ':')
+	if i <= 0 || i == len(word)-1 || strings.Contains(word, "://") {
+		return "", "", false
+	}
+	return word[:i], word[i+1:], true
+}
+
+// isPriority returns true if text starts with a priority like "(A) "
+func isPriority(text string) bool {
+	return len(text) >= 4 && text[0] == '(' && text[1] >= 'A' && text[1] <= 'Z' && text[2] == ')' && text[3] == ' '
+}
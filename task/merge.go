@@ -0,0 +1,137 @@
+package task
+
+import (
+	"sort"
+	"time"
+)
+
+// Conflict is a todo which was changed differently in both lists given to Merge.
+// A nil side means that the todo was deleted in that list.
+type Conflict struct {
+	Date   time.Time
+	Ours   *Todo
+	Theirs *Todo
+}
+
+// Merge combines the changes that were made to base in ours and in theirs. Days are identified by their date and
+// todos by their day and text, so changing the description, priority or dates or the day of a todo is a deletion
+// and an addition.
+//
+// A day or todo which was changed in only one of the lists is taken from that list, including additions and
+// deletions. Todos which were changed differently in both lists are left out of the result and returned as
+// conflicts, sorted like a DayList. The result is sorted in the given order.
+func Merge(base, ours, theirs DayList, order Order) (DayList, []Conflict) {
+	b, o, t := mergeIndex(base), mergeIndex(ours), mergeIndex(theirs)
+	texts := mergeOrder(ours, theirs, base)
+
+	merged := NewStoreInOrder(nil, order)
+	var conflicts []Conflict
+	for key, date := range mergeDates(ours, theirs, base) {
+		bDay, bOk := b[key]
+		oDay, oOk := o[key]
+		tDay, tOk := t[key]
+		if oOk == tOk || oOk == bOk {
+			if tOk {
+				merged.Insert(date, nil)
+			}
+		} else if oOk {
+			merged.Insert(date, nil)
+		}
+
+		for _, text := range texts[key] {
+			bTodo, bHas := bDay[text]
+			oTodo, oHas := oDay[text]
+			tTodo, tHas := tDay[text]
+
+			var todo Todo
+			var has bool
+			switch {
+			case oHas == tHas && oTodo == tTodo, oHas == bHas && oTodo == bTodo:
+				todo, has = tTodo, tHas
+			case tHas == bHas && tTodo == bTodo:
+				todo, has = oTodo, oHas
+			default:
+				c := Conflict{Date: date}
+				if oHas {
+					c.Ours = &oTodo
+				}
+				if tHas {
+					c.Theirs = &tTodo
+				}
+				conflicts = append(conflicts, c)
+				// The day of a conflict is kept, so the conflict can be written to it
+				merged.Insert(date, nil)
+				continue
+			}
+			if has {
+				merged.InsertTodo(date, todo)
+			}
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		a, b := conflicts[i], conflicts[j]
+		if !sameDay(a.Date, b.Date) {
+			return a.Date.After(b.Date)
+		}
+		return conflictDescription(a) < conflictDescription(b)
+	})
+	return merged.DayList(), conflicts
+}
+
+// mergeIndex maps the days of the list to their todos by text
+func mergeIndex(list DayList) map[dayKey]map[string]Todo {
+	index := make(map[dayKey]map[string]Todo, len(list))
+	for _, day := range list {
+		key := keyOf(day.Date)
+		if index[key] == nil {
+			index[key] = make(map[string]Todo, len(day.Todos))
+		}
+		for _, todo := range day.Todos {
+			index[key][todo.Text()] = todo
+		}
+	}
+	return index
+}
+
+// mergeOrder returns the texts of the todos of every day in the order they appear in the lists, so that the
+// merged days keep that order in ManualOrder
+func mergeOrder(lists ...DayList) map[dayKey][]string {
+	order := map[dayKey][]string{}
+	seen := map[dayKey]map[string]bool{}
+	for _, list := range lists {
+		for _, day := range list {
+			key := keyOf(day.Date)
+			if seen[key] == nil {
+				seen[key] = map[string]bool{}
+			}
+			for _, todo := range day.Todos {
+				if !seen[key][todo.Text()] {
+					seen[key][todo.Text()] = true
+					order[key] = append(order[key], todo.Text())
+				}
+			}
+		}
+	}
+	return order
+}
+
+// mergeDates returns the dates of all days in the given lists
+func mergeDates(lists ...DayList) map[dayKey]time.Time {
+	dates := map[dayKey]time.Time{}
+	for _, list := range lists {
+		for _, day := range list {
+			if _, ok := dates[keyOf(day.Date)]; !ok {
+				dates[keyOf(day.Date)] = day.Date
+			}
+		}
+	}
+	return dates
+}
+
+func conflictDescription(c Conflict) string {
+	if c.Ours != nil {
+		return c.Ours.Description
+	}
+	return c.Theirs.Description
+}
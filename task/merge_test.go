@@ -0,0 +1,80 @@
+package task
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	date1, err := time.Parse("02.01.06", "01.01.20")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+	date2 := date1.AddDate(0, 0, 1)
+	date3 := date1.AddDate(0, 0, 2)
+
+	base := DayList{
+		{Date: date1, Todos: TodoList{{Description: "A"}, {Description: "B"}, {Description: "C"}}},
+		{Date: date2, Todos: TodoList{{Description: "D"}}},
+	}
+	ours := DayList{
+		{Date: date1, Todos: TodoList{{Description: "A", Complete: true}, {Description: "C"}, {Description: "E"}}},
+		{Date: date2, Todos: TodoList{{Description: "D"}}},
+	}
+	theirs := DayList{
+		{Date: date1, Todos: TodoList{{Description: "A"}, {Description: "B", Complete: true}, {Description: "C"}}},
+		{Date: date3, Todos: TodoList{{Description: "F"}}},
+	}
+
+	merged, conflicts := Merge(base, ours, theirs, DefaultOrder)
+	assert.Equal(
+		t,
+		DayList{
+			{Date: date3, Todos: TodoList{{Description: "F"}}},
+			{Date: date1, Todos: TodoList{{Description: "A", Complete: true}, {Description: "C"}, {Description: "E"}}},
+		},
+		merged,
+		"Changes made to only one of the lists are not merged")
+	assert.Equal(
+		t,
+		[]Conflict{{Date: date1, Ours: nil, Theirs: &Todo{Description: "B", Complete: true}}},
+		conflicts,
+		"A todo deleted in one list and changed in the other is no conflict")
+}
+
+func TestMergeConflicts(t *testing.T) {
+	date, err := time.Parse("02.01.06", "01.01.20")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+
+	base := DayList{{Date: date, Todos: TodoList{{Description: "A"}, {Description: "B"}}}}
+	ours := DayList{{Date: date, Todos: TodoList{{Description: "A", Complete: true}, {Description: "B"},
+		{Description: "C"}}}}
+	theirs := DayList{{Date: date, Todos: TodoList{{Description: "A", Priority: "B"}, {Description: "B"},
+		{Description: "C"}}}}
+
+	merged, conflicts := Merge(base, ours, theirs, DefaultOrder)
+	assert.Equal(
+		t,
+		DayList{{Date: date, Todos: TodoList{{Description: "A", Priority: "B"}, {Description: "B"},
+			{Description: "C"}}}},
+		merged,
+		"Todos added the same way to both lists or with a new priority are not merged")
+	assert.Equal(
+		t,
+		[]Conflict{{Date: date, Ours: &Todo{Description: "A", Complete: true}, Theirs: nil}},
+		conflicts,
+		"Todos changed differently in both lists are not reported as conflict")
+}
+
+func TestMergeDeletedDay(t *testing.T) {
+	date1, err := time.Parse("02.01.06", "01.01.20")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+	date2 := date1.AddDate(0, 0, 1)
+
+	base := DayList{{Date: date1, Todos: TodoList{}}, {Date: date2, Todos: TodoList{{Description: "A"}}}}
+	ours := DayList{{Date: date2, Todos: TodoList{{Description: "A"}}}}
+	theirs := DayList{{Date: date1, Todos: TodoList{}}}
+
+	merged, conflicts := Merge(base, ours, theirs, DefaultOrder)
+	assert.Equal(t, DayList{}, merged, "Days deleted in either list are not deleted")
+	assert.Empty(t, conflicts, "Deleted days are reported as conflict")
+}
@@ -0,0 +1,247 @@
+package task
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Order is the order of the todos of every day and of the days of a list. The zero Order is the DefaultOrder.
+type Order struct {
+	Todos TodoOrder
+	Days  DayOrder
+}
+
+// DefaultOrder sorts done todos first and the newest day first
+var DefaultOrder = Order{Todos: StatusOrder, Days: NewestFirst}
+
+// Sort sorts the days of the list and the todos of every day in place
+func (o Order) Sort(list DayList) {
+	o.Days.Sort(list)
+	for _, day := range list {
+		o.Todos.Sort(day.Todos)
+	}
+}
+
+// TodoOrder is the order of the todos of a day. It compares todos by a list of keys, where later keys decide
+// between todos which are equal in all earlier keys. Todos which are equal in all keys are sorted by description.
+// The zero TodoOrder is the StatusOrder.
+type TodoOrder struct {
+	keys   []orderKey
+	manual bool
+}
+
+type orderKey struct {
+	name    string
+	compare func(a, b Todo) int
+	reverse bool
+}
+
+// Names of the keys of a TodoOrder
+const (
+	keyStatus     = "status"
+	keyPriority   = "priority"
+	keyAlphabetic = "alphabetic"
+	keyCreated    = "created"
+	keyTag        = "tag"
+	keyManual     = "manual"
+)
+
+var orderKeys = map[string]func(a, b Todo) int{
+	keyStatus:     compareStatus,
+	keyPriority:   comparePriority,
+	keyAlphabetic: compareDescription,
+	keyCreated:    compareCreated,
+	keyTag:        compareTag,
+}
+
+// StatusOrder sorts done todos before open ones and both by description
+var StatusOrder = TodoOrder{}
+
+// ManualOrder keeps the todos in the order they were inserted in. A changed todo keeps its position and a new todo
+// is appended to its day.
+var ManualOrder = TodoOrder{manual: true}
+
+// ParseTodoOrder parses a comma separated list of keys like "priority,-created". The keys are status, which sorts
+// done todos first, priority, which sorts the highest priority first, alphabetic, created, which sorts the oldest
+// todo first, and tag, which sorts by the first project or context of the description. A key starting with '-'
+// sorts the other way round. Todos without priority, creation date or tag are sorted last. The key manual keeps the
+// order the todos were inserted in and cannot be combined with other keys.
+func ParseTodoOrder(s string) (TodoOrder, error) {
+	if strings.TrimSpace(s) == keyManual {
+		return ManualOrder, nil
+	}
+	var order TodoOrder
+	for _, name := range strings.Split(s, ",") {
+		key := orderKey{name: strings.TrimSpace(name)}
+		if strings.HasPrefix(key.name, "-") {
+			key.name, key.reverse = key.name[1:], true
+		}
+		key.compare = orderKeys[key.name]
+		if key.compare == nil {
+			if key.name == keyManual {
+				return StatusOrder, fmt.Errorf("The order %s cannot be combined with other keys", keyManual)
+			}
+			return StatusOrder, fmt.Errorf("Unknown order %q, expected %s, %s, %s, %s, %s or %s", name, keyStatus,
+				keyPriority, keyAlphabetic, keyCreated, keyTag, keyManual)
+		}
+		order.keys = append(order.keys, key)
+	}
+	return order, nil
+}
+
+// Manual returns true if the order keeps the todos in the order they were inserted in
+func (o TodoOrder) Manual() bool {
+	return o.manual
+}
+
+// Less returns true if todo a is sorted before todo b. In ManualOrder no todo is sorted before another.
+func (o TodoOrder) Less(a, b Todo) bool {
+	if o.manual {
+		return false
+	}
+	if c := o.compare(a, b); c != 0 {
+		return c < 0
+	}
+	if a.Description != b.Description {
+		return a.Description < b.Description
+	}
+	return a.Text() < b.Text()
+}
+
+// compare compares the todos by the keys of the order only
+func (o TodoOrder) compare(a, b Todo) int {
+	if len(o.keys) == 0 {
+		return compareStatus(a, b)
+	}
+	for _, key := range o.keys {
+		if c := key.compare(a, b); c != 0 {
+			if key.reverse {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// Sort sorts the list unless the order is manual
+func (o TodoOrder) Sort(t TodoList) {
+	if !o.manual {
+		sort.Slice(t, func(i, j int) bool { return o.Less(t[i], t[j]) })
+	}
+}
+
+// byStatus returns true if the order only depends on the status and the text of the todos, so that a todo
+// can be found by binary search knowing only these
+func (o TodoOrder) byStatus() bool {
+	for _, key := range o.keys {
+		if key.name != keyStatus && key.name != keyAlphabetic {
+			return false
+		}
+	}
+	return !o.manual
+}
+
+func compareStatus(a, b Todo) int {
+	switch {
+	case a.Complete == b.Complete:
+		return 0
+	case a.Complete:
+		return -1
+	}
+	return 1
+}
+
+func comparePriority(a, b Todo) int {
+	return compareMissingLast(a.Priority, b.Priority)
+}
+
+func compareDescription(a, b Todo) int {
+	return strings.Compare(a.Description, b.Description)
+}
+
+func compareCreated(a, b Todo) int {
+	switch {
+	case a.Created.Equal(b.Created):
+		return 0
+	case a.Created.IsZero():
+		return 1
+	case b.Created.IsZero():
+		return -1
+	case a.Created.Before(b.Created):
+		return -1
+	}
+	return 1
+}
+
+func compareTag(a, b Todo) int {
+	return compareMissingLast(firstTag(a), firstTag(b))
+}
+
+// firstTag returns the first project or context of the description of the todo
+func firstTag(t Todo) string {
+	for _, word := range strings.Fields(t.Description) {
+		if len(word) > 1 && (word[0] == '+' || word[0] == '@') {
+			return word[1:]
+		}
+	}
+	return ""
+}
+
+// compareMissingLast compares the strings, sorting empty strings last
+func compareMissingLast(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
+// DayOrder is the order of the days of a DayList. The zero DayOrder is NewestFirst.
+type DayOrder int
+
+// Orders of the days of a DayList
+const (
+	NewestFirst DayOrder = iota
+	OldestFirst
+)
+
+// ParseDayOrder parses the name of a DayOrder, which is newest or oldest
+func ParseDayOrder(s string) (DayOrder, error) {
+	switch strings.TrimSpace(s) {
+	case "newest":
+		return NewestFirst, nil
+	case "oldest":
+		return OldestFirst, nil
+	}
+	return NewestFirst, fmt.Errorf("Unknown order of days %q, expected newest or oldest", s)
+}
+
+// Less returns true if day a is sorted before day b
+func (o DayOrder) Less(a, b Day) bool {
+	if o == OldestFirst {
+		return a.Date.Before(b.Date)
+	}
+	return a.Date.After(b.Date)
+}
+
+// dayBefore returns true if the day of date a is sorted before the day of date b, ignoring the time of day
+func (o DayOrder) dayBefore(a, b time.Time) bool {
+	ka, kb := keyOf(a), keyOf(b)
+	if ka == kb {
+		return false
+	}
+	later := ka.year > kb.year || ka.year == kb.year && ka.yearDay > kb.yearDay
+	return later == (o == NewestFirst)
+}
+
+// Sort sorts the days of the list
+func (o DayOrder) Sort(t DayList) {
+	sort.Slice(t, func(i, j int) bool { return o.Less(t[i], t[j]) })
+}
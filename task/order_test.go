@@ -0,0 +1,90 @@
+package task
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseTodoOrder(t *testing.T) {
+	order, err := ParseTodoOrder("priority, -created")
+	assert.Equal(t, nil, err, "Error for parsing order is not nil")
+	assert.Equal(t, []string{keyPriority, keyCreated}, []string{order.keys[0].name, order.keys[1].name},
+		"Keys of the order are not parsed")
+	assert.Equal(t, true, order.keys[1].reverse, "Key starting with '-' is not reversed")
+
+	order, err = ParseTodoOrder("manual")
+	assert.Equal(t, nil, err, "Error for parsing manual order is not nil")
+	assert.Equal(t, true, order.Manual(), "Manual order is not parsed")
+
+	_, err = ParseTodoOrder("status,manual")
+	assert.NotEqual(t, nil, err, "Manual combined with other keys is not reported")
+	_, err = ParseTodoOrder("size")
+	assert.NotEqual(t, nil, err, "Unknown key is not reported")
+}
+
+func TestTodoOrder_Sort(t *testing.T) {
+	created, err := time.Parse("2006-01-02", "2020-01-01")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+
+	todos := TodoList{
+		{Description: "D +work"},
+		{Description: "C", Priority: "B", Created: created},
+		{Description: "B", Complete: true, Priority: "A"},
+		{Description: "A @home", Created: created.AddDate(0, 0, 1)},
+	}
+	tests := []struct {
+		order    string
+		expected []string
+	}{
+		{"status", []string{"B", "A @home", "C", "D +work"}},
+		{"-status", []string{"A @home", "C", "D +work", "B"}},
+		{"priority", []string{"B", "C", "A @home", "D +work"}},
+		{"-alphabetic", []string{"D +work", "C", "B", "A @home"}},
+		{"created", []string{"C", "A @home", "B", "D +work"}},
+		{"tag,status", []string{"A @home", "D +work", "B", "C"}},
+	}
+	for _, test := range tests {
+		order, err := ParseTodoOrder(test.order)
+		assert.Equal(t, nil, err, "Error for parsing order is not nil")
+		sorted := make(TodoList, len(todos))
+		copy(sorted, todos)
+		order.Sort(sorted)
+
+		var descriptions []string
+		for _, todo := range sorted {
+			descriptions = append(descriptions, todo.Description)
+		}
+		assert.Equal(t, test.expected, descriptions, "Todos are not sorted by "+test.order)
+	}
+}
+
+func TestStoreInOrder(t *testing.T) {
+	byPriority, err := ParseTodoOrder("priority")
+	assert.Equal(t, nil, err, "Error for parsing order is not nil")
+	date, err := time.Parse("02.01.06", "01.01.20")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+
+	store := NewStoreInOrder(nil, Order{Todos: byPriority, Days: OldestFirst})
+	store.Insert(date, TodoList{{Description: "B"}, {Description: "C", Priority: "B"}})
+	store.InsertTodo(date.AddDate(0, 0, -1), Todo{Description: "D"})
+	store.InsertTodo(date, Todo{Description: "A", Priority: "A"})
+	store.InsertTodo(date, Todo{Description: "B", Priority: "C"})
+	assert.Equal(
+		t,
+		DayList{
+			{Date: date.AddDate(0, 0, -1), Todos: TodoList{{Description: "D"}}},
+			{Date: date, Todos: TodoList{{Description: "A", Priority: "A"}, {Description: "C", Priority: "B"},
+				{Description: "B", Priority: "C"}, {Description: "B"}}},
+		},
+		store.DayList(),
+		"Store is not sorted in its order")
+
+	todoList := TodoList{{Description: "B"}}
+	todoList.InsertTodoInOrder(Todo{Description: "A", Priority: "A"}, byPriority)
+	todoList.InsertTodoInOrder(Todo{Description: "B", Priority: "A"}, byPriority)
+	assert.Equal(t, TodoList{{Description: "A", Priority: "A"}, {Description: "B", Priority: "A"}, {Description: "B"}},
+		todoList,
+		"TodoList is not sorted in the order")
+	assert.Equal(t, DefaultOrder, Order{}, "Zero order is not the default order")
+}
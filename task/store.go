@@ -0,0 +1,170 @@
+package task
+
+import (
+	"time"
+)
+
+// Store is a date indexed collection of Days.
+// Looking up, setting and deleting a day as well as inserting a todo take constant time, no matter how many days
+// the store holds. Days and todos are only sorted when the content is requested through DayList or Day.
+type Store struct {
+	days  map[dayKey]*entry
+	order Order
+
+	//list caches the result of DayList until the store is changed
+	list DayList
+}
+
+// dayKey identifies a calendar day independently of the time of day and location of a time.Time
+type dayKey struct {
+	year, yearDay int
+}
+
+func keyOf(date time.Time) dayKey {
+	return dayKey{date.Year(), date.YearDay()}
+}
+
+// entry is a single day of the store together with an index of the texts of its todos
+type entry struct {
+	day    Day
+	index  map[string]int
+	order  TodoOrder
+	sorted bool
+}
+
+func newEntry(day Day, order TodoOrder) *entry {
+	e := &entry{day: Day{Date: day.Date}, index: make(map[string]int, len(day.Todos)), order: order, sorted: true}
+	for _, todo := range day.Todos {
+		e.insertTodo(todo)
+	}
+	return e
+}
+
+// insertTodo follows the rules of TodoList.InsertTodoInOrder but defers sorting until the day is read
+func (e *entry) insertTodo(td Todo) {
+	if i, ok := e.index[td.Text()]; ok {
+		if e.day.Todos[i] != td {
+			e.sorted = e.sorted && (e.order.manual || e.order.compare(e.day.Todos[i], td) == 0)
+			e.day.Todos[i] = td
+		}
+		return
+	}
+
+	e.index[td.Text()] = len(e.day.Todos)
+	e.day.Todos = append(e.day.Todos, td)
+	e.sorted = e.sorted && (e.order.manual || len(e.day.Todos) == 1 ||
+		e.order.Less(e.day.Todos[len(e.day.Todos)-2], td))
+}
+
+func (e *entry) sort() {
+	if e.sorted || e.order.manual {
+		return
+	}
+	e.order.Sort(e.day.Todos)
+	for i, todo := range e.day.Todos {
+		e.index[todo.Text()] = i
+	}
+	e.sorted = true
+}
+
+// copyDay returns the sorted day of the entry without sharing its TodoList with the store
+func (e *entry) copyDay() Day {
+	e.sort()
+	todos := make(TodoList, len(e.day.Todos))
+	copy(todos, e.day.Todos)
+	return Day{Date: e.day.Date, Todos: todos}
+}
+
+// NewStore returns a Store containing all days of the given DayList in the DefaultOrder.
+// Days with the same date are merged like InsertTodo would do.
+func NewStore(list DayList) *Store {
+	return NewStoreInOrder(list, DefaultOrder)
+}
+
+// NewStoreInOrder returns a Store like NewStore whose days and todos are sorted in the given order
+func NewStoreInOrder(list DayList, order Order) *Store {
+	s := &Store{days: make(map[dayKey]*entry, len(list)), order: order}
+	for _, day := range list {
+		s.Insert(day.Date, day.Todos)
+	}
+	return s
+}
+
+// Len returns the number of days in the store
+func (s *Store) Len() int {
+	return len(s.days)
+}
+
+// HasDate returns true if the store contains a day for the given date
+func (s *Store) HasDate(date time.Time) bool {
+	_, ok := s.days[keyOf(date)]
+	return ok
+}
+
+// Day returns a sorted copy of the day for the given date or a newly initialized day
+// for the date if the store does not contain it yet
+func (s *Store) Day(date time.Time) Day {
+	e, ok := s.days[keyOf(date)]
+	if !ok {
+		return Day{date, TodoList{}}
+	}
+	return e.copyDay()
+}
+
+// SetDay puts the given day into the store. If the store already contains a day for the date it is overwritten.
+func (s *Store) SetDay(day Day) {
+	s.list = nil
+	s.days[keyOf(day.Date)] = newEntry(day, s.order.Todos)
+}
+
+// DeleteDay removes the day for the given date from the store
+func (s *Store) DeleteDay(date time.Time) {
+	s.list = nil
+	delete(s.days, keyOf(date))
+}
+
+// InsertTodo inserts the todo into the day for the given date following the rules of TodoList.InsertTodoInOrder
+func (s *Store) InsertTodo(date time.Time, todo Todo) {
+	s.list = nil
+	s.entry(date).insertTodo(todo)
+}
+
+// Insert inserts all todos of the TodoList into the day for the given date
+func (s *Store) Insert(date time.Time, todos TodoList) {
+	s.list = nil
+	e := s.entry(date)
+	for _, todo := range todos {
+		e.insertTodo(todo)
+	}
+}
+
+func (s *Store) entry(date time.Time) *entry {
+	key := keyOf(date)
+	e, ok := s.days[key]
+	if !ok {
+		e = newEntry(Day{Date: date}, s.order.Todos)
+		s.days[key] = e
+	}
+	return e
+}
+
+// DayList returns all days of the store as a DayList sorted in the order of the store, with every TodoList
+// sorted as well. The result does not share any memory with the store.
+func (s *Store) DayList() DayList {
+	if s.list == nil {
+		s.list = make(DayList, 0, len(s.days))
+		for _, e := range s.days {
+			e.sort()
+			s.list = append(s.list, e.day)
+		}
+		s.order.Days.Sort(s.list)
+	}
+
+	list := make(DayList, len(s.list))
+	for i, day := range s.list {
+		todos := make(TodoList, len(day.Todos))
+		copy(todos, day.Todos)
+		list[i] = Day{Date: day.Date, Todos: todos}
+	}
+	return list
+}
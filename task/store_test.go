@@ -0,0 +1,117 @@
+package task
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestStore_InsertTodo(t *testing.T) {
+	testDate1, err := time.Parse("02.01.06", "01.01.20")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+	testDate2 := testDate1.AddDate(0, 0, 1)
+
+	store := NewStore(nil)
+	store.InsertTodo(testDate1, Todo{Description: "B", Complete: false})
+	store.InsertTodo(testDate1, Todo{Description: "A", Complete: false})
+	store.InsertTodo(testDate2, Todo{Description: "C", Complete: false})
+	store.InsertTodo(testDate1, Todo{Description: "B", Complete: true})
+	store.InsertTodo(testDate1, Todo{Description: "A", Complete: false})
+
+	expectedDayList := DayList{
+		{Date: testDate2, Todos: TodoList{{Description: "C", Complete: false}}},
+		{Date: testDate1, Todos: TodoList{{Description: "B", Complete: true}, {Description: "A", Complete: false}}},
+	}
+	assert.Equal(
+		t,
+		expectedDayList,
+		store.DayList(),
+		"DayList of the store is not sorted or contains duplicate todos")
+
+	assert.Equal(
+		t,
+		expectedDayList[1],
+		store.Day(testDate1.Add(10*time.Hour)),
+		"Day does not ignore the time of day")
+}
+
+func TestStore_SetDay(t *testing.T) {
+	testDate1, err := time.Parse("02.01.06", "01.01.20")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+
+	day := Day{Date: testDate1, Todos: TodoList{{Description: "Test", Complete: false}}}
+	store := NewStore(DayList{day})
+	assert.True(t, store.HasDate(testDate1), "Store does not contain day it was created with")
+
+	replacement := Day{Date: testDate1, Todos: TodoList{{Description: "Test1", Complete: true}}}
+	store.SetDay(replacement)
+	assert.Equal(t, DayList{replacement}, store.DayList(), "SetDay did not overwrite the existing day")
+
+	store.DeleteDay(testDate1)
+	assert.False(t, store.HasDate(testDate1), "Store still contains day after DeleteDay")
+	assert.Equal(t, 0, store.Len(), "Store is not empty after deleting its only day")
+}
+
+func TestStore_DayListIsCopy(t *testing.T) {
+	testDate1, err := time.Parse("02.01.06", "01.01.20")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+
+	store := NewStore(nil)
+	store.InsertTodo(testDate1, Todo{Description: "Test", Complete: false})
+
+	list := store.DayList()
+	list[0].Todos[0].Complete = true
+	assert.False(
+		t,
+		store.DayList()[0].Todos[0].Complete,
+		"Changing the returned DayList changed the store")
+}
+
+// benchmarkTodos returns 100k todos spread over roughly three years of days
+func benchmarkTodos() ([]time.Time, []Todo) {
+	const n = 100000
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	dates := make([]time.Time, n)
+	todos := make([]Todo, n)
+	for i := range todos {
+		dates[i] = start.AddDate(0, 0, i%1000)
+		todos[i] = Todo{Description: "Todo " + strconv.Itoa(i), Complete: i%3 == 0}
+	}
+	return dates, todos
+}
+
+func BenchmarkStore_InsertTodo(b *testing.B) {
+	dates, todos := benchmarkTodos()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		store := NewStore(nil)
+		for i, todo := range todos {
+			store.InsertTodo(dates[i], todo)
+		}
+		store.DayList()
+	}
+}
+
+func BenchmarkStore_HasDate(b *testing.B) {
+	dates, todos := benchmarkTodos()
+	store := NewStore(nil)
+	for i, todo := range todos {
+		store.InsertTodo(dates[i], todo)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		store.HasDate(dates[n%len(dates)])
+	}
+}
+
+func BenchmarkDayList_InsertTodo(b *testing.B) {
+	dates, todos := benchmarkTodos()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		var list DayList
+		for i, todo := range todos {
+			list.InsertTodo(dates[i], todo)
+		}
+	}
+}
@@ -3,7 +3,6 @@ package task
 import (
 	"fmt"
 	"sort"
-	"strings"
 	"time"
 )
 
@@ -13,13 +12,19 @@ var errOob = fmt.Errorf("index out of bounds")
 type Todo struct {
 	Description string
 	Complete    bool
+
+	// Priority is a single upper case letter from A to Z, where A is the highest priority, or empty
+	Priority string
+	// Created and Completed are the dates the todo was created and completed at, if they are known
+	Created   time.Time
+	Completed time.Time
 }
 
 func (t Todo) String() string {
 	if t.Complete {
-		return "- [x] " + t.Description
+		return "- [x] " + t.Text()
 	}
-	return "- [ ] " + t.Description
+	return "- [ ] " + t.Text()
 }
 
 // TodoList is a simple list of Todos
@@ -41,34 +46,80 @@ func (t TodoList) Swap(i, j int) {
 }
 
 func (t TodoList) Less(i, j int) bool {
-	if t[i].Complete && !t[j].Complete {
-		return true
-	} else if !t[i].Complete && t[j].Complete {
-		return false
+	return StatusOrder.Less(t[i], t[j])
+}
+
+// Move moves the todo at index from to index to. The todos in between move by one position.
+func (t TodoList) Move(from, to int) error {
+	if from < 0 || from >= len(t) || to < 0 || to >= len(t) {
+		return errOob
+	}
+	todo := t[from]
+	if from < to {
+		copy(t[from:to], t[from+1:to+1])
 	} else {
-		return strings.Compare(t[i].Description, t[j].Description) == -1
+		copy(t[to+1:from+1], t[to:from])
 	}
+	t[to] = todo
+	return nil
 }
 
 //InsertTodo checks if a Todo is already in the todo list and if not adds it
-//In case the Todo is already in the list but has a different Complete Status, the todo will be overwritten
+//In case a Todo with the same text is already in the list but has a different Complete Status, the todo will
+//be overwritten
+//
+//The list is expected to be sorted, which holds for every list that is only built through InsertTodo and Insert.
+//The position of the todo is found by binary search so that the list stays sorted without sorting it again.
 func (t *TodoList) InsertTodo(td Todo) {
-	for i, todo := range *t {
-		if todo.Description == td.Description {
-			if todo.Complete != td.Complete {
-				newList := append((*t)[:i], td)
-				if len(*t)-1 > i {
-					newList = append(newList, (*t)[i+1:]...)
-				}
-				*t = newList
-				sort.Sort(t)
-			}
+	t.InsertTodoInOrder(td, StatusOrder)
+}
+
+// InsertTodoInOrder inserts the todo like InsertTodo into a list sorted in the given order. In ManualOrder a changed
+// todo keeps its position and a new one is appended.
+func (t *TodoList) InsertTodoInOrder(td Todo, order TodoOrder) {
+	if order.manual {
+		if i, ok := t.find(td, order); ok {
+			(*t)[i] = td
+		} else {
+			*t = append(*t, td)
+		}
+		return
+	}
+
+	if i, ok := t.find(td, order); ok {
+		if (*t)[i] == td {
 			return
 		}
+		*t = append((*t)[:i], (*t)[i+1:]...)
+	}
+
+	i := sort.Search(len(*t), func(i int) bool { return !order.Less((*t)[i], td) })
+	*t = append(*t, Todo{})
+	copy((*t)[i+1:], (*t)[i:])
+	(*t)[i] = td
+}
+
+// find returns the index of the todo with the same text as td in the list sorted in the order. If the order only
+// depends on status and text, both partitions of completed and open todos are searched by binary search.
+func (t TodoList) find(td Todo, order TodoOrder) (int, bool) {
+	text := td.Text()
+	if !order.byStatus() {
+		for i, todo := range t {
+			if todo.Text() == text {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+	for _, complete := range []bool{true, false} {
+		probe := td
+		probe.Complete = complete
+		i := sort.Search(len(t), func(i int) bool { return !order.Less(t[i], probe) })
+		if i < len(t) && t[i].Text() == text {
+			return i, true
+		}
 	}
-	newList := append(*t, td)
-	*t = newList
-	sort.Sort(t)
+	return 0, false
 }
 
 // Insert inserts all Todos from the parameter TodoList into the list on which the method is called
@@ -90,13 +141,13 @@ func (t DayList) Swap(i, j int) {
 }
 
 func (t DayList) Less(i, j int) bool {
-	return t[i].Date.After(t[j].Date)
+	return NewestFirst.Less(t[i], t[j])
 }
 
 // HasDate returns true if the DayList contains a date with the given date
 func (t DayList) HasDate(date time.Time) bool {
 	for _, d := range t {
-		if date == d.Date {
+		if sameDay(date, d.Date) {
 			return true
 		}
 	}
@@ -107,7 +158,7 @@ func (t DayList) HasDate(date time.Time) bool {
 //for the date if the list does not contain it yet
 func (t DayList) DayByDate(date time.Time) Day {
 	for _, d := range t {
-		if date.YearDay() == d.Date.YearDay() && date.Year() == d.Date.Year() {
+		if sameDay(date, d.Date) {
 			return d
 		}
 	}
@@ -118,41 +169,41 @@ func (t DayList) DayByDate(date time.Time) Day {
 // SetDay inserts a given day into the DayList. If the day is already in the list it is overwritten otherwise
 // it is simply appended
 func (t *DayList) SetDay(day Day) {
-	var newList DayList
-	if t.HasDate(day.Date) {
-		for _, d := range *t {
-			if d.Date.Year() == day.Date.Year() && day.Date.YearDay() == d.Date.YearDay() {
-				newList = append(newList, day)
-			} else {
-				newList = append(newList, d)
-			}
+	for i, d := range *t {
+		if sameDay(day.Date, d.Date) {
+			(*t)[i] = day
+			return
 		}
-	} else {
-		newList = append(*t, day)
 	}
 
-	*t = newList
+	*t = append(*t, day)
 }
 
 // DeleteDay removes a day completely from the DayList
 func (t *DayList) DeleteDay(date time.Time) {
-	var newList []Day
-	if t.HasDate(date) {
-		for _, d := range *t {
-			if !(d.Date.Year() == date.Year() && date.YearDay() == d.Date.YearDay()) {
-				newList = append(newList, d)
-			}
+	for i, d := range *t {
+		if sameDay(date, d.Date) {
+			*t = append((*t)[:i], (*t)[i+1:]...)
+			return
 		}
 	}
-	*t = newList
 }
 
 // InsertTodo inserts the todo into the day of this DayList corresponding to the given date
 func (t *DayList) InsertTodo(date time.Time, todo Todo) {
-	day := t.DayByDate(date)
-	day.Todos.InsertTodo(todo)
-	t.SetDay(day)
-	sort.Sort(t)
+	t.InsertTodoInOrder(date, todo, DefaultOrder)
+}
+
+// InsertTodoInOrder inserts the todo like InsertTodo into a list sorted in the given order. The day is found by
+// binary search, and a new day is inserted at its position, so the list stays sorted without sorting it again.
+func (t *DayList) InsertTodoInOrder(date time.Time, todo Todo, order Order) {
+	i := sort.Search(len(*t), func(i int) bool { return !order.Days.dayBefore((*t)[i].Date, date) })
+	if i == len(*t) || !sameDay((*t)[i].Date, date) {
+		*t = append(*t, Day{})
+		copy((*t)[i+1:], (*t)[i:])
+		(*t)[i] = Day{Date: date, Todos: TodoList{}}
+	}
+	(*t)[i].Todos.InsertTodoInOrder(todo, order.Todos)
 }
 
 // DeleteTodo delets the todo from the day of this DayList corresponding to the given date
@@ -185,3 +236,7 @@ func (t *DayList) TransformToDayBasedIndex(ind int) (date time.Time, transInd in
 	err = errOob
 	return
 }
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
@@ -28,6 +28,26 @@ func TestTodoList_InsertTodo(t *testing.T) {
 		"Actual TodoList changed after re-inserting todo which was already in the list.")
 }
 
+func TestTodoList_InsertTodoKeepsOrder(t *testing.T) {
+	var todoList TodoList
+	for _, desc := range []string{"D", "B", "C", "A"} {
+		todoList.InsertTodo(Todo{Description: desc, Complete: false})
+	}
+	todoList.InsertTodo(Todo{Description: "C", Complete: true})
+
+	assert.True(t, sort.IsSorted(todoList), "TodoList is not sorted after InsertTodo")
+	assert.Equal(
+		t,
+		TodoList{
+			{Description: "C", Complete: true},
+			{Description: "A", Complete: false},
+			{Description: "B", Complete: false},
+			{Description: "D", Complete: false},
+		},
+		todoList,
+		"InsertTodo did not overwrite the status of an existing todo")
+}
+
 func TestTodoList_Insert(t *testing.T) {
 	var todoList TodoList
 	todo1 := Todo{Description: "Test", Complete: false}
@@ -109,3 +129,62 @@ func TestDayList_SetDay(t *testing.T) {
 		"DayList does not contain updated day after inserting a new Todo into a day.")
 
 }
+
+func TestDayList_InsertTodoInOrder(t *testing.T) {
+	date, err := time.Parse("02.01.06", "02.01.20")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+	dates := []time.Time{date, date.AddDate(0, 0, -1), date.AddDate(0, 0, 1), date.Add(time.Hour)}
+
+	for _, order := range []DayOrder{NewestFirst, OldestFirst} {
+		var dayList DayList
+		for i, d := range dates {
+			dayList.InsertTodoInOrder(d, Todo{Description: "Todo " + string(rune('A'+i))}, Order{Days: order})
+		}
+		expected := DayList{
+			{Date: date.AddDate(0, 0, -1), Todos: TodoList{{Description: "Todo B"}}},
+			{Date: date, Todos: TodoList{{Description: "Todo A"}, {Description: "Todo D"}}},
+			{Date: date.AddDate(0, 0, 1), Todos: TodoList{{Description: "Todo C"}}},
+		}
+		order.Sort(expected)
+		assert.Equal(t, expected, dayList, "Days are not inserted at their position in the order")
+	}
+}
+
+func TestTodoList_Move(t *testing.T) {
+	todoList := TodoList{{Description: "A"}, {Description: "B"}, {Description: "C"}, {Description: "D"}}
+
+	assert.Equal(t, nil, todoList.Move(3, 1), "Error for moving todo up is not nil")
+	assert.Equal(
+		t,
+		TodoList{{Description: "A"}, {Description: "D"}, {Description: "B"}, {Description: "C"}},
+		todoList,
+		"Todo was not moved up")
+
+	assert.Equal(t, nil, todoList.Move(0, 2), "Error for moving todo down is not nil")
+	assert.Equal(
+		t,
+		TodoList{{Description: "D"}, {Description: "B"}, {Description: "A"}, {Description: "C"}},
+		todoList,
+		"Todo was not moved down")
+
+	assert.Equal(t, errOob, todoList.Move(0, 4), "Moving todo out of the list is not reported")
+}
+
+func TestManualOrder(t *testing.T) {
+	date, err := time.Parse("02.01.06", "01.01.20")
+	assert.Equal(t, nil, err, "Error for parsing date is not nil")
+
+	store := NewStoreInOrder(nil, Order{Todos: ManualOrder})
+	store.Insert(date, TodoList{{Description: "C"}, {Description: "A", Complete: true}, {Description: "B"}})
+	store.InsertTodo(date, Todo{Description: "C", Complete: true})
+	store.InsertTodo(date, Todo{Description: "0"})
+	expectedTodoList := TodoList{{Description: "C", Complete: true}, {Description: "A", Complete: true},
+		{Description: "B"}, {Description: "0"}}
+	assert.Equal(t, expectedTodoList, store.Day(date).Todos, "Store does not keep the order todos were inserted in")
+
+	todoList := store.Day(date).Todos
+	todoList.InsertTodoInOrder(Todo{Description: "A"}, ManualOrder)
+	ManualOrder.Sort(todoList)
+	expectedTodoList[1].Complete = false
+	assert.Equal(t, expectedTodoList, todoList, "TodoList does not keep the order todos were inserted in")
+}